@@ -13,6 +13,56 @@ _ghinst() {
         -http-timeout)
             return
             ;;
+        -manifest)
+            _filedir
+            return
+            ;;
+        -bin)
+            return
+            ;;
+        -as)
+            return
+            ;;
+        -asset)
+            return
+            ;;
+        -os)
+            COMPREPLY=($(compgen -W "linux darwin windows" -- "$cur"))
+            return
+            ;;
+        -arch)
+            COMPREPLY=($(compgen -W "amd64 arm64 386 arm riscv64 ppc64le s390x loong64 mips mipsle mips64 mips64le" -- "$cur"))
+            return
+            ;;
+        -output)
+            _filedir -d
+            return
+            ;;
+        -from-file)
+            _filedir
+            return
+            ;;
+        -sha256)
+            return
+            ;;
+        -cache-dir)
+            _filedir -d
+            return
+            ;;
+        -cache-max-size)
+            return
+            ;;
+        -jobs)
+            return
+            ;;
+        -cosign-key)
+            _filedir
+            return
+            ;;
+        -keys)
+            _filedir
+            return
+            ;;
         -completion)
             COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur"))
             return
@@ -20,7 +70,7 @@ _ghinst() {
     esac
 
     if [[ "$cur" == -* ]]; then
-        COMPREPLY=($(compgen -W "-completion -version -purge -list -force -dir -max-size -http-timeout" -- "$cur"))
+        COMPREPLY=($(compgen -W "-completion -version -purge -list -force -dir -max-size -http-timeout -upgrade -outdated -manifest -locked -uninstall -use -all -bin -as -asset -explain -os -arch -output -download-only -from-file -sha256 -cache-dir -cache-max-size -cache-clean -quiet -jobs -require-checksum -cosign-key -keys -verify-attestation" -- "$cur"))
         return
     fi
 }
@@ -8,3 +8,29 @@ complete -c ghinst -o force      -d 'Install even if already on the latest versi
 complete -c ghinst -o dir        -d 'Base install directory' -r -a '(__fish_complete_directories)'
 complete -c ghinst -o max-size   -d 'Maximum asset or extracted binary size in bytes; supports kb, mb, gb suffixes' -r
 complete -c ghinst -o http-timeout -d 'HTTP timeout; supports time.ParseDuration formats' -r
+complete -c ghinst -o upgrade -d 'Upgrade every installed owner/repo to its latest release'
+complete -c ghinst -o outdated -d 'Report installed owner/repo versions behind their latest release'
+complete -c ghinst -o manifest -d 'Install every tool listed in a ghinst.toml manifest' -r -F
+complete -c ghinst -o locked -d 'With -manifest, install exactly what its lockfile records'
+complete -c ghinst -o uninstall -d 'Remove every version of owner/repo and its symlinks'
+complete -c ghinst -o use -d 'Switch owner/repo@version to an already installed version'
+complete -c ghinst -o all -d 'Install every executable in the archive, not just the first'
+complete -c ghinst -o bin -d 'Install the archive entry with this base name or glob (repeatable)' -r
+complete -c ghinst -o as -d 'Name of the symlink created in the bin directory' -r
+complete -c ghinst -o asset -d 'Glob or /regexp/ selecting the release asset to install' -r
+complete -c ghinst -o explain -d 'Show how release assets rank for this platform, without installing'
+complete -c ghinst -o os -d 'Select assets for this GOOS instead of the running one' -r -a 'linux darwin windows'
+complete -c ghinst -o arch -d 'Select assets for this GOARCH instead of the running one' -r -a 'amd64 arm64 386 arm riscv64 ppc64le s390x loong64 mips mipsle mips64 mips64le'
+complete -c ghinst -o output -d 'Write the extracted binaries to this directory instead of installing them' -r -a '(__fish_complete_directories)'
+complete -c ghinst -o download-only -d 'Save the verified asset and a .sha256 file to -output without installing'
+complete -c ghinst -o from-file -d 'Install owner/repo@version from this local asset file instead of downloading it' -r -F
+complete -c ghinst -o sha256 -d 'With -from-file, the expected sha256 of the file in hex' -r
+complete -c ghinst -o cache-dir -d 'Download cache directory; empty disables the cache' -r -a '(__fish_complete_directories)'
+complete -c ghinst -o cache-max-size -d 'Evict the oldest cached downloads beyond this size; supports kb, mb, gb suffixes' -r
+complete -c ghinst -o cache-clean -d 'Remove every cached download'
+complete -c ghinst -o quiet -d 'Do not show download progress'
+complete -c ghinst -o jobs -d 'Install at most this many targets at once' -r
+complete -c ghinst -o require-checksum -d 'Fail instead of warn when an asset has no checksum to verify against'
+complete -c ghinst -o cosign-key -d 'Require cosign/Sigstore signatures verified against the public keys in this file' -r -F
+complete -c ghinst -o keys -d 'File of per-repo minisign and gpg keys that checksum files must be signed with' -r -F
+complete -c ghinst -o verify-attestation -d 'Require a GitHub attestation with SLSA provenance from owner/repo for the asset'
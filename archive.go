@@ -15,73 +15,202 @@ import (
 	"github.com/ulikunitz/xz"
 )
 
-// extractBinary extracts the binary from an archive into a temp file.
-// For non-archives (raw binary), it returns the input file as-is.
-func extractBinary(f *os.File, assetName string, maxBytes int64) (string, *os.File, error) {
+// binarySelection chooses which archive entries are installed: for each of
+// the given names or globs, the first entry whose base name matches it, every
+// executable when all is set, or otherwise the first executable.
+type binarySelection struct {
+	names []string
+	all   bool
+}
+
+func (s binarySelection) multiple() bool {
+	return s.all || len(s.names) > 1
+}
+
+// claim reports whether name matches a pattern that no earlier entry has
+// matched, and records the patterns it matches in matched.
+func (s binarySelection) claim(name string, matched map[string]bool) bool {
+	claimed := false
+	for _, pattern := range s.names {
+		if ok, _ := filepath.Match(pattern, name); ok && !matched[pattern] {
+			matched[pattern] = true
+			claimed = true
+		}
+	}
+
+	return claimed
+}
+
+func validateBinaryPattern(pattern string) error {
+	if err := validatePathComponent("binary name", pattern); err != nil {
+		return err
+	}
+
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid binary name %q: %w", pattern, err)
+	}
+
+	return nil
+}
+
+type extractedBinary struct {
+	name string
+	file *os.File
+}
+
+func closeExtracted(bins []extractedBinary) {
+	for _, b := range bins {
+		b.file.Close()
+		os.Remove(b.file.Name())
+	}
+}
+
+// extractBinaries extracts the selected binaries from an archive into temp
+// files. For non-archives (raw binary), it returns the input file as-is.
+func extractBinaries(f *os.File, assetName string, sel binarySelection, maxBytes int64) ([]extractedBinary, error) {
 	lower := strings.ToLower(assetName)
 	switch {
 	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
 		gz, err := gzip.NewReader(f)
 		if err != nil {
-			return "", nil, err
+			return nil, err
 		}
 
 		defer gz.Close()
-		return findInTar(tar.NewReader(gz), maxBytes)
+		return findInTar(tar.NewReader(gz), sel, maxBytes)
 
 	case strings.HasSuffix(lower, ".tar.bz2"):
-		return findInTar(tar.NewReader(bzip2.NewReader(f)), maxBytes)
+		return findInTar(tar.NewReader(bzip2.NewReader(f)), sel, maxBytes)
 
 	case strings.HasSuffix(lower, ".tar.xz"):
 		xzr, err := xz.NewReader(f)
 		if err != nil {
-			return "", nil, err
+			return nil, err
 		}
 
-		return findInTar(tar.NewReader(xzr), maxBytes)
+		return findInTar(tar.NewReader(xzr), sel, maxBytes)
 
 	case strings.HasSuffix(lower, ".tar.zst"):
 		zr, err := zstd.NewReader(f)
 		if err != nil {
-			return "", nil, err
+			return nil, err
 		}
 
 		defer zr.Close()
-		return findInTar(tar.NewReader(zr), maxBytes)
+		return findInTar(tar.NewReader(zr), sel, maxBytes)
 
 	case strings.HasSuffix(lower, ".zip"):
 		info, err := f.Stat()
 		if err != nil {
-			return "", nil, err
+			return nil, err
 		}
 
-		return findInZip(f, info.Size(), maxBytes)
+		return findInZip(f, info.Size(), sel, maxBytes)
 
 	case strings.HasSuffix(lower, ".zst"):
 		zr, err := zstd.NewReader(f)
 		if err != nil {
-			return "", nil, err
+			return nil, err
 		}
 
 		defer zr.Close()
 
 		tmp, err := writeTempFile(zr, maxBytes)
 		if err != nil {
-			return "", nil, err
+			return nil, err
 		}
 
-		return strings.TrimSuffix(filepath.Base(assetName), ".zst"), tmp, nil
+		return []extractedBinary{{strings.TrimSuffix(filepath.Base(assetName), ".zst"), tmp}}, nil
 	}
 
 	if err := validateFileSize(f, maxBytes); err != nil {
-		return "", nil, err
+		return nil, err
+	}
+
+	return []extractedBinary{{rawBinaryName(assetName), f}}, nil
+}
+
+// rawBinaryName derives the installed name of a raw binary asset by dropping
+// its .bin or .AppImage suffix and everything from the first OS, architecture
+// or version component on, so tool_1.2.3_linux_amd64 installs as tool.
+func rawBinaryName(assetName string) string {
+	name, ext := filepath.Base(assetName), ""
+	for _, suffix := range []string{".exe", ".bin", ".appimage"} {
+		if strings.HasSuffix(strings.ToLower(name), suffix) {
+			name = name[:len(name)-len(suffix)]
+			if suffix == ".exe" {
+				ext = suffix
+			}
+
+			break
+		}
+	}
+
+	lower := strings.ToLower(name)
+	for i := 1; i < len(lower); i++ {
+		if isAlnum(lower[i-1]) || !isAlnum(lower[i]) || !isPlatformComponent(lower[i:]) {
+			continue
+		}
+
+		if base := strings.TrimRight(name[:i], "-_."); base != "" {
+			return base + ext
+		}
+	}
+
+	return name + ext
+}
+
+// isPlatformComponent reports whether s starts with a version number, an OS
+// alias or a whole architecture alias.
+func isPlatformComponent(s string) bool {
+	if isDigit(s[0]) || len(s) > 1 && s[0] == 'v' && isDigit(s[1]) {
+		return true
 	}
 
-	return assetName, f, nil
+	for _, words := range osAliases {
+		if startsWithWord(s, words, false) {
+			return true
+		}
+	}
+
+	for _, words := range archAliases {
+		if startsWithWord(s, words, true) {
+			return true
+		}
+	}
+
+	return false
 }
 
-// findInTar returns the first executable file in a tar archive as a temp file.
-func findInTar(tr *tar.Reader, maxBytes int64) (string, *os.File, error) {
+// startsWithWord reports whether s starts with any of words, followed by a
+// non-alphanumeric character or the end of s when whole is set.
+func startsWithWord(s string, words []string, whole bool) bool {
+	for _, w := range words {
+		if strings.HasPrefix(s, w) && (!whole || len(s) == len(w) || !isAlnum(s[len(w)])) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// findInTar returns the selected files in a tar archive as temp files. Named
+// entries need not be executable; otherwise only executables are considered.
+func findInTar(tr *tar.Reader, sel binarySelection, maxBytes int64) (_ []extractedBinary, err error) {
+	var bins []extractedBinary
+	defer func() {
+		if err != nil {
+			closeExtracted(bins)
+		}
+	}()
+
+	seen := map[string]bool{}
+	matched := map[string]bool{}
+	var executables []string
 	for {
 		hdr, err := tr.Next()
 		if err == io.EOF {
@@ -89,58 +218,145 @@ func findInTar(tr *tar.Reader, maxBytes int64) (string, *os.File, error) {
 		}
 
 		if err != nil {
-			return "", nil, err
+			return nil, err
 		}
 
-		if hdr.Typeflag != tar.TypeReg || hdr.FileInfo().Mode()&0111 == 0 {
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := filepath.Base(hdr.Name)
+		if hdr.FileInfo().Mode()&0111 != 0 {
+			executables = append(executables, name)
+		}
+
+		if seen[name] {
+			continue
+		}
+
+		if len(sel.names) > 0 {
+			if !sel.claim(name, matched) {
+				continue
+			}
+		} else if hdr.FileInfo().Mode()&0111 == 0 {
 			continue
 		}
 
 		if hdr.Size > maxBytes {
-			return "", nil, fmt.Errorf("binary size %d bytes exceeds limit of %d bytes", hdr.Size, maxBytes)
+			return nil, fmt.Errorf("binary size %d bytes exceeds limit of %d bytes", hdr.Size, maxBytes)
 		}
 
 		tmp, err := writeTempFile(tr, maxBytes)
 		if err != nil {
-			return "", nil, err
+			return nil, err
+		}
+
+		seen[name] = true
+		bins = append(bins, extractedBinary{name, tmp})
+		if !sel.multiple() {
+			return bins, nil
 		}
+	}
 
-		return filepath.Base(hdr.Name), tmp, nil
+	if err := checkSelectionFound(sel, seen, executables); err != nil {
+		return nil, err
 	}
 
-	return "", nil, fmt.Errorf("no executable found in archive")
+	return bins, nil
 }
 
-// findInZip returns the first executable file in a zip archive as a temp file.
+// findInZip returns the selected files in a zip archive as temp files.
 // Falls back to likely executable names if no exec bits are set.
-func findInZip(r io.ReaderAt, size int64, maxBytes int64) (string, *os.File, error) {
+func findInZip(r io.ReaderAt, size int64, sel binarySelection, maxBytes int64) (_ []extractedBinary, err error) {
 	zr, err := zip.NewReader(r, size)
 	if err != nil {
-		return "", nil, err
+		return nil, err
 	}
 
-	best := pickZipBinary(zr.File)
-	if best == nil {
-		return "", nil, fmt.Errorf("no executable found in archive")
+	var files []*zip.File
+	switch {
+	case len(sel.names) > 0:
+		files = findZipFiles(zr.File, sel)
+	case sel.all:
+		files = zipExecutables(zr.File)
 	}
 
-	if int64(best.UncompressedSize64) > maxBytes {
-		return "", nil, fmt.Errorf("binary size %d bytes exceeds limit of %d bytes", best.UncompressedSize64, maxBytes)
+	if len(files) == 0 && len(sel.names) == 0 {
+		if best := pickZipBinary(zr.File); best != nil {
+			files = []*zip.File{best}
+		}
 	}
 
-	rc, err := best.Open()
+	var bins []extractedBinary
+	defer func() {
+		if err != nil {
+			closeExtracted(bins)
+		}
+	}()
+
+	seen := map[string]bool{}
+	for _, f := range files {
+		if int64(f.UncompressedSize64) > maxBytes {
+			return nil, fmt.Errorf("binary size %d bytes exceeds limit of %d bytes", f.UncompressedSize64, maxBytes)
+		}
+
+		tmp, err := extractZipFile(f, maxBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		seen[filepath.Base(f.Name)] = true
+		bins = append(bins, extractedBinary{filepath.Base(f.Name), tmp})
+	}
+
+	if err := checkSelectionFound(sel, seen, zipExecutableNames(zr.File)); err != nil {
+		return nil, err
+	}
+
+	return bins, nil
+}
+
+func extractZipFile(f *zip.File, maxBytes int64) (*os.File, error) {
+	rc, err := f.Open()
 	if err != nil {
-		return "", nil, err
+		return nil, err
 	}
 
 	defer rc.Close()
 
-	tmp, err := writeTempFile(rc, maxBytes)
-	if err != nil {
-		return "", nil, err
+	return writeTempFile(rc, maxBytes)
+}
+
+// checkSelectionFound reports a selected name or glob that matched nothing,
+// listing the executables the archive does have.
+func checkSelectionFound(sel binarySelection, found map[string]bool, executables []string) error {
+	for _, pattern := range sel.names {
+		matched := false
+		for name := range found {
+			if ok, _ := filepath.Match(pattern, name); ok {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return fmt.Errorf("no file matching %q found in archive%s", pattern, availableExecutables(executables))
+		}
 	}
 
-	return filepath.Base(best.Name), tmp, nil
+	if len(found) == 0 {
+		return fmt.Errorf("no executable found in archive")
+	}
+
+	return nil
+}
+
+func availableExecutables(names []string) string {
+	if len(names) == 0 {
+		return " (no executables)"
+	}
+
+	return "; available executables: " + strings.Join(names, ", ")
 }
 
 func writeTempFile(r io.Reader, maxBytes int64) (*os.File, error) {
@@ -190,3 +406,53 @@ func pickZipBinary(files []*zip.File) *zip.File {
 
 	return noExtFallback
 }
+
+// zipExecutables returns every file with exec bits or an .exe extension,
+// keeping the first of any that share a base name.
+func zipExecutables(files []*zip.File) []*zip.File {
+	var exes []*zip.File
+	seen := map[string]bool{}
+	for _, f := range files {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		base := filepath.Base(f.Name)
+		if seen[base] {
+			continue
+		}
+
+		if f.Mode()&0111 != 0 || strings.EqualFold(filepath.Ext(base), ".exe") {
+			seen[base] = true
+			exes = append(exes, f)
+		}
+	}
+
+	return exes
+}
+
+func zipExecutableNames(files []*zip.File) []string {
+	var names []string
+	for _, f := range zipExecutables(files) {
+		names = append(names, filepath.Base(f.Name))
+	}
+
+	return names
+}
+
+func findZipFiles(files []*zip.File, sel binarySelection) []*zip.File {
+	var picked []*zip.File
+	seen := map[string]bool{}
+	matched := map[string]bool{}
+	for _, f := range files {
+		base := filepath.Base(f.Name)
+		if f.FileInfo().IsDir() || seen[base] || !sel.claim(base, matched) {
+			continue
+		}
+
+		seen[base] = true
+		picked = append(picked, f)
+	}
+
+	return picked
+}
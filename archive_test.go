@@ -7,6 +7,7 @@ import (
 	"compress/gzip"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -14,6 +15,15 @@ import (
 	"github.com/ulikunitz/xz"
 )
 
+// firstExtracted unpacks the single binary of a default selection.
+func firstExtracted(bins []extractedBinary, err error) (string, *os.File, error) {
+	if err != nil {
+		return "", nil, err
+	}
+
+	return bins[0].name, bins[0].file, nil
+}
+
 func buildTarGz(files []struct {
 	name string
 	mode int64
@@ -67,7 +77,7 @@ func TestFindInTar(t *testing.T) {
 		t.Fatalf("gzip.NewReader: %v", err)
 	}
 
-	name, tmp, err := findInTar(tar.NewReader(gr), 1<<20)
+	name, tmp, err := firstExtracted(findInTar(tar.NewReader(gr), binarySelection{}, 1<<20))
 	if err != nil {
 		t.Fatalf("findInTar: unexpected error: %v", err)
 	}
@@ -99,12 +109,244 @@ func TestFindInTar(t *testing.T) {
 		t.Fatalf("gzip.NewReader no-exec: %v", err)
 	}
 
-	_, _, err = findInTar(tar.NewReader(gr2), 1<<20)
+	_, _, err = firstExtracted(findInTar(tar.NewReader(gr2), binarySelection{}, 1<<20))
 	if err == nil {
 		t.Error("findInTar: expected error for archive with no executables")
 	}
 }
 
+func TestFindInTarByName(t *testing.T) {
+	data, err := buildTarGz([]struct {
+		name string
+		mode int64
+		body []byte
+	}{
+		{"dist/helper", 0755, []byte("helper")},
+		{"dist/tool", 0644, []byte("tool")},
+	})
+	if err != nil {
+		t.Fatalf("buildTarGz: %v", err)
+	}
+
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+
+	name, tmp, err := firstExtracted(findInTar(tar.NewReader(gr), binarySelection{names: []string{"tool"}}, 1<<20))
+	if err != nil {
+		t.Fatalf("findInTar: unexpected error: %v", err)
+	}
+
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if name != "tool" {
+		t.Errorf("findInTar name = %q, want %q", name, "tool")
+	}
+
+	got, _ := io.ReadAll(tmp)
+	if string(got) != "tool" {
+		t.Errorf("findInTar content = %q, want %q", got, "tool")
+	}
+
+	gr2, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+
+	if _, err := findInTar(tar.NewReader(gr2), binarySelection{names: []string{"missing"}}, 1<<20); err == nil || !strings.Contains(err.Error(), `no file matching "missing"`) {
+		t.Errorf("findInTar missing name error = %v", err)
+	}
+}
+
+func TestFindInTarSelectsMultiple(t *testing.T) {
+	data, err := buildTarGz([]struct {
+		name string
+		mode int64
+		body []byte
+	}{
+		{"bin/server", 0755, []byte("server")},
+		{"README.md", 0644, []byte("readme")},
+		{"bin/cli", 0755, []byte("cli")},
+		{"contrib/completions.sh", 0755, []byte("completions")},
+	})
+	if err != nil {
+		t.Fatalf("buildTarGz: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		sel  binarySelection
+		want []string
+	}{
+		{"all", binarySelection{all: true}, []string{"server", "cli", "completions.sh"}},
+		{"subset", binarySelection{names: []string{"cli", "server"}}, []string{"server", "cli"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			gr, err := gzip.NewReader(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("gzip.NewReader: %v", err)
+			}
+
+			bins, err := findInTar(tar.NewReader(gr), tc.sel, 1<<20)
+			if err != nil {
+				t.Fatalf("findInTar: %v", err)
+			}
+
+			defer closeExtracted(bins)
+
+			var got []string
+			for _, b := range bins {
+				got = append(got, b.name)
+				body, _ := io.ReadAll(b.file)
+				if want := strings.TrimSuffix(b.name, ".sh"); string(body) != want {
+					t.Errorf("%s content = %q, want %q", b.name, body, want)
+				}
+			}
+
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Fatalf("findInTar names = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFindInTarSubsetRequiresEveryName(t *testing.T) {
+	data, err := buildTarGz([]struct {
+		name string
+		mode int64
+		body []byte
+	}{{"cli", 0755, []byte("cli")}})
+	if err != nil {
+		t.Fatalf("buildTarGz: %v", err)
+	}
+
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+
+	tmpBefore, _ := filepath.Glob(filepath.Join(os.TempDir(), "ghinst-bin-*"))
+	_, err = findInTar(tar.NewReader(gr), binarySelection{names: []string{"cli", "server"}}, 1<<20)
+	if err == nil || !strings.Contains(err.Error(), `no file matching "server"`) {
+		t.Fatalf("findInTar error = %v, want missing server", err)
+	}
+
+	tmpAfter, _ := filepath.Glob(filepath.Join(os.TempDir(), "ghinst-bin-*"))
+	if len(tmpAfter) > len(tmpBefore) {
+		t.Fatalf("extracted temp files leaked: %v", tmpAfter)
+	}
+}
+
+func TestFindInTarByGlobListsExecutablesOnMiss(t *testing.T) {
+	data, err := buildTarGz([]struct {
+		name string
+		mode int64
+		body []byte
+	}{
+		{"gen-completions", 0755, []byte("gen")},
+		{"tool-linux-amd64", 0755, []byte("tool")},
+		{"README.md", 0644, []byte("readme")},
+	})
+	if err != nil {
+		t.Fatalf("buildTarGz: %v", err)
+	}
+
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+
+	name, tmp, err := firstExtracted(findInTar(tar.NewReader(gr), binarySelection{names: []string{"tool*"}}, 1<<20))
+	if err != nil {
+		t.Fatalf("findInTar: %v", err)
+	}
+
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if name != "tool-linux-amd64" {
+		t.Fatalf("findInTar glob name = %q, want %q", name, "tool-linux-amd64")
+	}
+
+	gr2, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+
+	_, err = findInTar(tar.NewReader(gr2), binarySelection{names: []string{"server*"}}, 1<<20)
+	if err == nil {
+		t.Fatal("findInTar expected error for unmatched glob")
+	}
+
+	want := `no file matching "server*" found in archive; available executables: gen-completions, tool-linux-amd64`
+	if err.Error() != want {
+		t.Fatalf("findInTar error = %q, want %q", err, want)
+	}
+}
+
+func TestFindInTarGlobTakesFirstMatch(t *testing.T) {
+	data, err := buildTarGz([]struct {
+		name string
+		mode int64
+		body []byte
+	}{
+		{"tool-cli", 0755, []byte("cli")},
+		{"tool-server", 0755, []byte("server")},
+		{"helper", 0755, []byte("helper")},
+	})
+	if err != nil {
+		t.Fatalf("buildTarGz: %v", err)
+	}
+
+	tests := []struct {
+		names []string
+		want  []string
+	}{
+		{[]string{"tool-*"}, []string{"tool-cli"}},
+		{[]string{"tool-*", "h*"}, []string{"tool-cli", "helper"}},
+	}
+
+	for _, tc := range tests {
+		gr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("gzip.NewReader: %v", err)
+		}
+
+		bins, err := findInTar(tar.NewReader(gr), binarySelection{names: tc.names}, 1<<20)
+		if err != nil {
+			t.Fatalf("findInTar(%q): %v", tc.names, err)
+		}
+
+		var got []string
+		for _, b := range bins {
+			got = append(got, b.name)
+		}
+
+		closeExtracted(bins)
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("findInTar(%q) = %q, want %q", tc.names, got, tc.want)
+		}
+	}
+}
+
+func TestValidateBinaryPattern(t *testing.T) {
+	for _, pattern := range []string{"tool", "tool-*", "tool.exe"} {
+		if err := validateBinaryPattern(pattern); err != nil {
+			t.Errorf("validateBinaryPattern(%q): %v", pattern, err)
+		}
+	}
+
+	for _, pattern := range []string{"", "..", "bin/tool", "[tool"} {
+		if err := validateBinaryPattern(pattern); err == nil {
+			t.Errorf("validateBinaryPattern(%q) expected error", pattern)
+		}
+	}
+}
+
 func buildZip(files []struct {
 	name string
 	mode os.FileMode
@@ -224,7 +466,7 @@ func TestFindInZip(t *testing.T) {
 		t.Fatalf("buildZip exec: %v", err)
 	}
 
-	name, tmp, err := findInZip(bytes.NewReader(data), int64(len(data)), 1<<20)
+	name, tmp, err := firstExtracted(findInZip(bytes.NewReader(data), int64(len(data)), binarySelection{}, 1<<20))
 	if err != nil {
 		t.Fatalf("findInZip exec: unexpected error: %v", err)
 	}
@@ -246,7 +488,7 @@ func TestFindInZip(t *testing.T) {
 		t.Fatalf("buildZip fallback noext: %v", err)
 	}
 
-	name2, tmp2, err := findInZip(bytes.NewReader(data2), int64(len(data2)), 1<<20)
+	name2, tmp2, err := firstExtracted(findInZip(bytes.NewReader(data2), int64(len(data2)), binarySelection{}, 1<<20))
 	if err != nil {
 		t.Fatalf("findInZip fallback: unexpected error: %v", err)
 	}
@@ -268,7 +510,7 @@ func TestFindInZip(t *testing.T) {
 		t.Fatalf("buildZip no candidates: %v", err)
 	}
 
-	_, _, err = findInZip(bytes.NewReader(data3), int64(len(data3)), 1<<20)
+	_, _, err = firstExtracted(findInZip(bytes.NewReader(data3), int64(len(data3)), binarySelection{}, 1<<20))
 	if err == nil {
 		t.Error("findInZip: expected error for archive with no candidates")
 	}
@@ -285,7 +527,7 @@ func TestFindInZip(t *testing.T) {
 		t.Fatalf("buildZip exe fallback: %v", err)
 	}
 
-	name4, tmp4, err := findInZip(bytes.NewReader(data4), int64(len(data4)), 1<<20)
+	name4, tmp4, err := firstExtracted(findInZip(bytes.NewReader(data4), int64(len(data4)), binarySelection{}, 1<<20))
 	if err != nil {
 		t.Fatalf("findInZip exe fallback: unexpected error: %v", err)
 	}
@@ -316,7 +558,7 @@ func TestExtractBinaryTarXz(t *testing.T) {
 	defer os.Remove(archive.Name())
 	defer archive.Close()
 
-	name, tmp, err := extractBinary(archive, "tool.tar.xz", 1<<20)
+	name, tmp, err := firstExtracted(extractBinaries(archive, "tool.tar.xz", binarySelection{}, 1<<20))
 	if err != nil {
 		t.Fatalf("extractBinary: unexpected error: %v", err)
 	}
@@ -357,7 +599,7 @@ func TestExtractBinaryTarZst(t *testing.T) {
 	defer os.Remove(archive.Name())
 	defer archive.Close()
 
-	name, tmp, err := extractBinary(archive, "tool.tar.zst", 1<<20)
+	name, tmp, err := firstExtracted(extractBinaries(archive, "tool.tar.zst", binarySelection{}, 1<<20))
 	if err != nil {
 		t.Fatalf("extractBinary: unexpected error: %v", err)
 	}
@@ -403,7 +645,7 @@ func TestExtractBinaryZst(t *testing.T) {
 	defer os.Remove(archive.Name())
 	defer archive.Close()
 
-	name, tmp, err := extractBinary(archive, "tool.zst", 1<<20)
+	name, tmp, err := firstExtracted(extractBinaries(archive, "tool.zst", binarySelection{}, 1<<20))
 	if err != nil {
 		t.Fatalf("extractBinary: unexpected error: %v", err)
 	}
@@ -434,7 +676,7 @@ func TestExtractBinaryRejectsOversizedRawBinary(t *testing.T) {
 	defer os.Remove(archive.Name())
 	defer archive.Close()
 
-	_, _, err = extractBinary(archive, "tool", 5)
+	_, _, err = firstExtracted(extractBinaries(archive, "tool", binarySelection{}, 5))
 	if err == nil {
 		t.Fatal("extractBinary expected error for oversized raw binary")
 	}
@@ -444,6 +686,47 @@ func TestExtractBinaryRejectsOversizedRawBinary(t *testing.T) {
 	}
 }
 
+func TestExtractBinaryRawBinaryName(t *testing.T) {
+	raw, err := writeTempFile(bytes.NewReader([]byte("bin")), 1<<20)
+	if err != nil {
+		t.Fatalf("writeTempFile: %v", err)
+	}
+
+	defer os.Remove(raw.Name())
+	defer raw.Close()
+
+	name, _, err := firstExtracted(extractBinaries(raw, "tool_1.2.3_linux_amd64", binarySelection{}, 1<<20))
+	if err != nil {
+		t.Fatalf("extractBinaries: %v", err)
+	}
+
+	if name != "tool" {
+		t.Fatalf("extractBinaries name = %q, want tool", name)
+	}
+}
+
+func TestRawBinaryName(t *testing.T) {
+	tests := []struct {
+		asset string
+		want  string
+	}{
+		{"tool", "tool"},
+		{"tool_linux_amd64", "tool"},
+		{"tool-v1.2.3-linux-x86_64", "tool"},
+		{"my-tool-darwin-arm64", "my-tool"},
+		{"tool_windows_amd64.exe", "tool.exe"},
+		{"tool-x86_64.AppImage", "tool"},
+		{"tool.bin", "tool"},
+		{"linux-amd64", "linux"},
+	}
+
+	for _, tc := range tests {
+		if got := rawBinaryName(tc.asset); got != tc.want {
+			t.Errorf("rawBinaryName(%q) = %q, want %q", tc.asset, got, tc.want)
+		}
+	}
+}
+
 func TestExtractBinaryRejectsOversizedArchiveMember(t *testing.T) {
 	content := []byte("123456")
 	data, err := buildTarGz([]struct {
@@ -463,7 +746,7 @@ func TestExtractBinaryRejectsOversizedArchiveMember(t *testing.T) {
 	defer os.Remove(archive.Name())
 	defer archive.Close()
 
-	_, _, err = extractBinary(archive, "tool.tar.gz", 5)
+	_, _, err = firstExtracted(extractBinaries(archive, "tool.tar.gz", binarySelection{}, 5))
 	if err == nil {
 		t.Fatal("extractBinary expected error for oversized archive member")
 	}
@@ -473,6 +756,103 @@ func TestExtractBinaryRejectsOversizedArchiveMember(t *testing.T) {
 	}
 }
 
+func TestFindInZipAll(t *testing.T) {
+	data, err := buildZip([]struct {
+		name string
+		mode os.FileMode
+		body []byte
+	}{
+		{"server", 0755, []byte("server")},
+		{"LICENSE", 0644, []byte("license")},
+		{"cli.exe", 0644, []byte("cli")},
+	})
+	if err != nil {
+		t.Fatalf("buildZip: %v", err)
+	}
+
+	bins, err := findInZip(bytes.NewReader(data), int64(len(data)), binarySelection{all: true}, 1<<20)
+	if err != nil {
+		t.Fatalf("findInZip: %v", err)
+	}
+
+	defer closeExtracted(bins)
+
+	if len(bins) != 2 || bins[0].name != "server" || bins[1].name != "cli.exe" {
+		t.Fatalf("findInZip all = %v, want server and cli.exe", bins)
+	}
+}
+
+func TestFindInZipByGlob(t *testing.T) {
+	data, err := buildZip([]struct {
+		name string
+		mode os.FileMode
+		body []byte
+	}{
+		{"helper.exe", 0644, []byte("helper")},
+		{"tool.exe", 0644, []byte("tool")},
+	})
+	if err != nil {
+		t.Fatalf("buildZip: %v", err)
+	}
+
+	name, tmp, err := firstExtracted(findInZip(bytes.NewReader(data), int64(len(data)), binarySelection{names: []string{"t*.exe"}}, 1<<20))
+	if err != nil {
+		t.Fatalf("findInZip: %v", err)
+	}
+
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if name != "tool.exe" {
+		t.Fatalf("findInZip glob name = %q, want %q", name, "tool.exe")
+	}
+
+	_, err = findInZip(bytes.NewReader(data), int64(len(data)), binarySelection{names: []string{"missing"}}, 1<<20)
+	if err == nil || !strings.Contains(err.Error(), "available executables: helper.exe, tool.exe") {
+		t.Fatalf("findInZip error = %v, want available executables listed", err)
+	}
+}
+
+func TestFindInZipGlobTakesFirstMatch(t *testing.T) {
+	data, err := buildZip([]struct {
+		name string
+		mode os.FileMode
+		body []byte
+	}{
+		{"tool-cli.exe", 0644, []byte("cli")},
+		{"tool-server.exe", 0644, []byte("server")},
+		{"helper.exe", 0644, []byte("helper")},
+	})
+	if err != nil {
+		t.Fatalf("buildZip: %v", err)
+	}
+
+	tests := []struct {
+		names []string
+		want  []string
+	}{
+		{[]string{"tool-*"}, []string{"tool-cli.exe"}},
+		{[]string{"tool-*", "h*"}, []string{"tool-cli.exe", "helper.exe"}},
+	}
+
+	for _, tc := range tests {
+		bins, err := findInZip(bytes.NewReader(data), int64(len(data)), binarySelection{names: tc.names}, 1<<20)
+		if err != nil {
+			t.Fatalf("findInZip(%q): %v", tc.names, err)
+		}
+
+		var got []string
+		for _, b := range bins {
+			got = append(got, b.name)
+		}
+
+		closeExtracted(bins)
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("findInZip(%q) = %q, want %q", tc.names, got, tc.want)
+		}
+	}
+}
+
 func TestPickZipBinary(t *testing.T) {
 	data, err := buildZip([]struct {
 		name string
@@ -0,0 +1,503 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	_ "embed"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sigstoreTrustedRootJSON is the trusted root of the public-good Sigstore
+// instance, which signs GitHub attestations of public repos: the Fulcio
+// certificate chains and the Rekor transparency log keys.
+//
+//go:embed _sigstore/trusted_root.json
+var sigstoreTrustedRootJSON []byte
+
+// githubActionsIssuer is the OIDC issuer of certificates Fulcio grants to
+// GitHub Actions workflows.
+const githubActionsIssuer = "https://token.actions.githubusercontent.com"
+
+// slsaProvenancePrefix starts the predicate type of every SLSA provenance
+// version.
+const slsaProvenancePrefix = "https://slsa.dev/provenance/"
+
+// maxAttestationsResponse bounds the size of the attestations API response.
+const maxAttestationsResponse int64 = 8 << 20
+
+// Fulcio certificate extensions identifying the workflow that signed.
+var (
+	oidFulcioIssuerV1      = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	oidFulcioRepositoryV1  = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 5}
+	oidFulcioIssuer        = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+	oidFulcioSourceRepoURI = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 12}
+)
+
+// verifyAttestations makes installs fail unless the asset has a GitHub
+// artifact attestation with SLSA provenance from its own repo.
+var verifyAttestations = false
+
+// sigstoreTrust is the parsed sigstoreTrustedRootJSON.
+var sigstoreTrust = sync.OnceValues(func() (*sigstoreTrustRoot, error) {
+	return parseSigstoreTrustedRoot(sigstoreTrustedRootJSON)
+})
+
+// sigstoreTrustRoot holds the certificate authorities and transparency log
+// keys attestation bundles are checked against.
+type sigstoreTrustRoot struct {
+	roots         *x509.CertPool
+	intermediates *x509.CertPool
+	logKeys       map[string]crypto.PublicKey // by raw log ID
+}
+
+func parseSigstoreTrustedRoot(data []byte) (*sigstoreTrustRoot, error) {
+	var tr struct {
+		Tlogs []struct {
+			PublicKey struct {
+				RawBytes []byte `json:"rawBytes"`
+			} `json:"publicKey"`
+			LogID struct {
+				KeyID []byte `json:"keyId"`
+			} `json:"logId"`
+		} `json:"tlogs"`
+		CertificateAuthorities []struct {
+			CertChain struct {
+				Certificates []struct {
+					RawBytes []byte `json:"rawBytes"`
+				} `json:"certificates"`
+			} `json:"certChain"`
+		} `json:"certificateAuthorities"`
+	}
+	if err := json.Unmarshal(data, &tr); err != nil {
+		return nil, fmt.Errorf("invalid trusted root: %w", err)
+	}
+
+	root := &sigstoreTrustRoot{
+		roots:         x509.NewCertPool(),
+		intermediates: x509.NewCertPool(),
+		logKeys:       make(map[string]crypto.PublicKey),
+	}
+
+	for _, tl := range tr.Tlogs {
+		key, err := x509.ParsePKIXPublicKey(tl.PublicKey.RawBytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted root log key: %w", err)
+		}
+
+		root.logKeys[string(tl.LogID.KeyID)] = key
+	}
+
+	for _, ca := range tr.CertificateAuthorities {
+		certs := ca.CertChain.Certificates
+		for i, c := range certs {
+			cert, err := x509.ParseCertificate(c.RawBytes)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted root certificate: %w", err)
+			}
+
+			// Chains run from the issuing intermediate to the root.
+			if i == len(certs)-1 {
+				root.roots.AddCert(cert)
+			} else {
+				root.intermediates.AddCert(cert)
+			}
+		}
+	}
+
+	return root, nil
+}
+
+// withAttestation marks asset for attestation verification against the
+// repo release was fetched from when -verify-attestation is set.
+func withAttestation(asset Asset, release Release) Asset {
+	if verifyAttestations {
+		asset.attestRepo = release.repo
+	}
+
+	return asset
+}
+
+// verifyAssetAttestation checks the downloaded bytes of asset in f against
+// the GitHub attestations published for their digest, and rewinds f.
+func verifyAssetAttestation(asset Asset, f *os.File) error {
+	if asset.attestRepo == "" {
+		return nil
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	h256, h512 := sha256.New(), sha512.New()
+	if _, err := io.Copy(io.MultiWriter(h256, h512), f); err != nil {
+		return err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	digests := map[string]string{
+		"sha256": hex.EncodeToString(h256.Sum(nil)),
+		"sha512": hex.EncodeToString(h512.Sum(nil)),
+	}
+
+	bundles, err := fetchAttestations(asset.attestRepo, "sha256:"+digests["sha256"])
+	if err != nil {
+		return err
+	}
+
+	return verifyAttestationBundles(bundles, digests, asset.attestRepo)
+}
+
+// fetchAttestations returns the attestation bundles GitHub has for an
+// artifact digest in owner/repo.
+func fetchAttestations(ownerRepo, digest string) ([]json.RawMessage, error) {
+	owner, repo, _ := strings.Cut(ownerRepo, "/")
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/attestations/%s", apiBase, url.PathEscape(owner), url.PathEscape(repo), url.PathEscape(digest))
+	resp, err := getGitHub(http.MethodGet, endpoint, authScopeAPI)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("no attestation found for %s in %s", digest, ownerRepo)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitHub API returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Attestations []struct {
+			Bundle json.RawMessage `json:"bundle"`
+		} `json:"attestations"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxAttestationsResponse)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("reading attestations: %w", err)
+	}
+
+	var bundles []json.RawMessage
+	for _, a := range body.Attestations {
+		if len(a.Bundle) > 0 && string(a.Bundle) != "null" {
+			bundles = append(bundles, a.Bundle)
+		}
+	}
+
+	if len(bundles) == 0 {
+		return nil, fmt.Errorf("no attestation found for %s in %s", digest, ownerRepo)
+	}
+
+	return bundles, nil
+}
+
+// verifyAttestationBundles succeeds when one of bundles is valid SLSA
+// provenance for an artifact with the given digests, keyed by algorithm,
+// built from ownerRepo.
+func verifyAttestationBundles(bundles []json.RawMessage, digests map[string]string, ownerRepo string) error {
+	trust, err := sigstoreTrust()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, b := range bundles {
+		err := verifyAttestationBundle(trust, b, digests, ownerRepo)
+		if err == nil {
+			return nil
+		}
+
+		errs = append(errs, err)
+	}
+
+	return fmt.Errorf("no valid provenance attestation from %s: %w", ownerRepo, errors.Join(errs...))
+}
+
+// verifyAttestationBundle checks a Sigstore bundle holding a DSSE-signed
+// in-toto statement: the transparency log promise fixes the signing time,
+// the certificate must chain to Fulcio as of then and belong to a GitHub
+// Actions workflow of ownerRepo, the signature must cover the statement,
+// and the statement must be SLSA provenance for digests built from
+// ownerRepo.
+func verifyAttestationBundle(trust *sigstoreTrustRoot, data []byte, digests map[string]string, ownerRepo string) error {
+	var b sigstoreBundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return fmt.Errorf("invalid bundle: %w", err)
+	}
+
+	env := b.DSSEEnvelope
+	if env == nil || len(env.Signatures) == 0 {
+		return errors.New("bundle has no DSSE envelope")
+	}
+
+	if env.PayloadType != "application/vnd.in-toto+json" {
+		return fmt.Errorf("unsupported payload type %q", env.PayloadType)
+	}
+
+	chain, err := b.certificateChain()
+	if err != nil {
+		return err
+	}
+
+	if len(chain) == 0 {
+		return errors.New("bundle has no signing certificate")
+	}
+
+	signedAt, err := verifyTlogEntries(trust, b.VerificationMaterial.TlogEntries, env.Payload)
+	if err != nil {
+		return err
+	}
+
+	leaf := chain[0]
+	intermediates := trust.intermediates.Clone()
+	for _, c := range chain[1:] {
+		intermediates.AddCert(c)
+	}
+
+	_, err = leaf.Verify(x509.VerifyOptions{
+		Roots:         trust.roots,
+		Intermediates: intermediates,
+		CurrentTime:   signedAt,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil {
+		return fmt.Errorf("untrusted signing certificate: %w", err)
+	}
+
+	if err := checkWorkflowCertificate(leaf, ownerRepo); err != nil {
+		return err
+	}
+
+	pae := dssePAE(env.PayloadType, env.Payload)
+	verified := false
+	for _, s := range env.Signatures {
+		if verifySignature(leaf.PublicKey, pae, s.Sig) == nil {
+			verified = true
+			break
+		}
+	}
+
+	if !verified {
+		return errors.New("attestation signature does not match its certificate")
+	}
+
+	return checkProvenance(env.Payload, digests, ownerRepo)
+}
+
+// tlogEntry is a Rekor transparency log entry in a Sigstore bundle.
+type tlogEntry struct {
+	LogIndex string `json:"logIndex"`
+	LogID    struct {
+		KeyID []byte `json:"keyId"`
+	} `json:"logId"`
+	IntegratedTime   string `json:"integratedTime"`
+	InclusionPromise *struct {
+		SignedEntryTimestamp []byte `json:"signedEntryTimestamp"`
+	} `json:"inclusionPromise"`
+	CanonicalizedBody []byte `json:"canonicalizedBody"`
+}
+
+// verifyTlogEntries returns the time a trusted log promised to include the
+// signed payload at, checking the log's signed entry timestamp and that the
+// entry is for payload.
+func verifyTlogEntries(trust *sigstoreTrustRoot, entries []tlogEntry, payload []byte) (time.Time, error) {
+	sum := sha256.Sum256(payload)
+	payloadHash := hex.EncodeToString(sum[:])
+
+	for _, e := range entries {
+		key, ok := trust.logKeys[string(e.LogID.KeyID)]
+		if !ok || e.InclusionPromise == nil {
+			continue
+		}
+
+		integrated, err := strconv.ParseInt(e.IntegratedTime, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid log entry time %q", e.IntegratedTime)
+		}
+
+		index, err := strconv.ParseInt(e.LogIndex, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid log entry index %q", e.LogIndex)
+		}
+
+		// Rekor signs the canonical JSON of the entry, keys sorted.
+		promised, err := json.Marshal(struct {
+			Body           []byte `json:"body"`
+			IntegratedTime int64  `json:"integratedTime"`
+			LogID          string `json:"logID"`
+			LogIndex       int64  `json:"logIndex"`
+		}{e.CanonicalizedBody, integrated, hex.EncodeToString(e.LogID.KeyID), index})
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if verifySignature(key, promised, e.InclusionPromise.SignedEntryTimestamp) != nil {
+			return time.Time{}, errors.New("transparency log promise does not verify")
+		}
+
+		var body struct {
+			Spec struct {
+				PayloadHash *struct {
+					Value string `json:"value"`
+				} `json:"payloadHash"`
+				Content struct {
+					PayloadHash *struct {
+						Value string `json:"value"`
+					} `json:"payloadHash"`
+				} `json:"content"`
+			} `json:"spec"`
+		}
+		if err := json.Unmarshal(e.CanonicalizedBody, &body); err != nil {
+			return time.Time{}, fmt.Errorf("invalid log entry: %w", err)
+		}
+
+		hash := body.Spec.PayloadHash
+		if hash == nil {
+			hash = body.Spec.Content.PayloadHash
+		}
+
+		if hash == nil || !strings.EqualFold(hash.Value, payloadHash) {
+			return time.Time{}, errors.New("transparency log entry is for a different attestation")
+		}
+
+		return time.Unix(integrated, 0), nil
+	}
+
+	return time.Time{}, errors.New("bundle has no transparency log entry from a trusted log")
+}
+
+// checkWorkflowCertificate checks that a Fulcio certificate was issued to a
+// GitHub Actions workflow running in ownerRepo.
+func checkWorkflowCertificate(cert *x509.Certificate, ownerRepo string) error {
+	issuer := certificateExtension(cert, oidFulcioIssuer, oidFulcioIssuerV1)
+	if issuer != githubActionsIssuer {
+		return fmt.Errorf("attestation was not signed by a GitHub Actions workflow (issuer %q)", issuer)
+	}
+
+	repo := certificateExtension(cert, oidFulcioSourceRepoURI, oidFulcioRepositoryV1)
+	if !strings.EqualFold(githubRepoName(repo), ownerRepo) {
+		return fmt.Errorf("attestation was signed by a workflow in %s, not %s", githubRepoName(repo), ownerRepo)
+	}
+
+	return nil
+}
+
+// certificateExtension returns the value of the Fulcio extension oid, a DER
+// string, or of the legacy extension legacyOID, raw bytes.
+func certificateExtension(cert *x509.Certificate, oid, legacyOID asn1.ObjectIdentifier) string {
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oid) {
+			var s string
+			if _, err := asn1.UnmarshalWithParams(ext.Value, &s, "utf8"); err == nil {
+				return s
+			}
+		}
+	}
+
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(legacyOID) {
+			return string(ext.Value)
+		}
+	}
+
+	return ""
+}
+
+// dssePAE is the DSSE pre-authentication encoding that envelope signatures
+// cover.
+func dssePAE(payloadType string, payload []byte) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "DSSEv1 %d %s %d ", len(payloadType), payloadType, len(payload))
+	b.Write(payload)
+	return b.Bytes()
+}
+
+// checkProvenance checks that an in-toto statement is SLSA provenance whose
+// subject has one of digests and whose source repository is ownerRepo.
+func checkProvenance(payload []byte, digests map[string]string, ownerRepo string) error {
+	var st struct {
+		Subject       []inTotoSubject `json:"subject"`
+		PredicateType string          `json:"predicateType"`
+		Predicate     struct {
+			// SLSA v1
+			BuildDefinition struct {
+				ExternalParameters struct {
+					Workflow struct {
+						Repository string `json:"repository"`
+					} `json:"workflow"`
+				} `json:"externalParameters"`
+			} `json:"buildDefinition"`
+			// SLSA v0.2
+			Invocation struct {
+				ConfigSource struct {
+					URI string `json:"uri"`
+				} `json:"configSource"`
+			} `json:"invocation"`
+		} `json:"predicate"`
+	}
+	if err := json.Unmarshal(payload, &st); err != nil {
+		return fmt.Errorf("invalid attestation statement: %w", err)
+	}
+
+	if !strings.HasPrefix(st.PredicateType, slsaProvenancePrefix) {
+		return fmt.Errorf("attestation is %s, not SLSA provenance", st.PredicateType)
+	}
+
+	if !subjectMatches(st.Subject, digests) {
+		return errors.New("attestation subject does not match the asset")
+	}
+
+	source := st.Predicate.BuildDefinition.ExternalParameters.Workflow.Repository
+	if source == "" {
+		source = st.Predicate.Invocation.ConfigSource.URI
+	}
+
+	if !strings.EqualFold(githubRepoName(source), ownerRepo) {
+		return fmt.Errorf("provenance source repository is %q, not %s", source, ownerRepo)
+	}
+
+	return nil
+}
+
+// inTotoSubject is an artifact an in-toto statement is about.
+type inTotoSubject struct {
+	Digest map[string]string `json:"digest"`
+}
+
+func subjectMatches(subjects []inTotoSubject, digests map[string]string) bool {
+	for _, s := range subjects {
+		for algo, want := range digests {
+			if got, ok := s.Digest[algo]; ok && strings.EqualFold(got, want) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// githubRepoName returns owner/repo from a GitHub repository URL such as
+// https://github.com/owner/repo or git+https://github.com/owner/repo@ref.
+func githubRepoName(uri string) string {
+	name := strings.TrimPrefix(uri, "git+")
+	name = strings.TrimPrefix(name, "https://github.com/")
+	name, _, _ = strings.Cut(name, "@")
+	return strings.TrimSuffix(name, ".git")
+}
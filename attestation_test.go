@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// recordedProvenanceDigest is the sha512 of the artifact, sigstore@1.3.0 on
+// npm, whose provenance testdata/bundle-provenance.json records. The bundle
+// was signed by a GitHub Actions workflow in sigstore/sigstore-js.
+const recordedProvenanceDigest = "76176ffa33808b54602c7c35de5c6e9a4deb96066dba6533f50ac234f4f1f4c6b3527515dc17c06fbe2860030f410eee69ea20079bd3a2c6f3dcf3b329b10751"
+
+func readRecordedBundle(t *testing.T) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile("testdata/bundle-provenance.json")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	return data
+}
+
+// editRecordedBundle returns the recorded bundle after edit changes its DSSE
+// envelope.
+func editRecordedBundle(t *testing.T, edit func(env map[string]any)) []byte {
+	t.Helper()
+
+	var b map[string]any
+	if err := json.Unmarshal(readRecordedBundle(t), &b); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	edit(b["dsseEnvelope"].(map[string]any))
+	data, err := json.Marshal(b)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	return data
+}
+
+// serveAttestations serves bundles from the attestations API of ownerRepo
+// for any digest and points apiBase at it.
+func serveAttestations(t *testing.T, ownerRepo string, bundles ...[]byte) *[]string {
+	t.Helper()
+
+	var digests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		digest, ok := strings.CutPrefix(r.URL.Path, "/repos/"+ownerRepo+"/attestations/")
+		if !ok || len(bundles) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+
+		digests = append(digests, digest)
+		var resp struct {
+			Attestations []map[string]json.RawMessage `json:"attestations"`
+		}
+		for _, b := range bundles {
+			resp.Attestations = append(resp.Attestations, map[string]json.RawMessage{"bundle": b})
+		}
+
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+
+	old := apiBase
+	apiBase = srv.URL
+	t.Cleanup(func() { apiBase = old })
+
+	return &digests
+}
+
+func TestVerifyAttestationBundlesRecorded(t *testing.T) {
+	digests := map[string]string{"sha512": recordedProvenanceDigest}
+	bundle := readRecordedBundle(t)
+
+	if err := verifyAttestationBundles([]json.RawMessage{bundle}, digests, "sigstore/sigstore-js"); err != nil {
+		t.Fatalf("verifyAttestationBundles: %v", err)
+	}
+
+	tamperedPayload := editRecordedBundle(t, func(env map[string]any) {
+		payload, _ := base64.StdEncoding.DecodeString(env["payload"].(string))
+		payload = bytes.Replace(payload, []byte("sigstore/sigstore-js"), []byte("attacker/sigstore-js"), -1)
+		env["payload"] = base64.StdEncoding.EncodeToString(payload)
+	})
+
+	tamperedSignature := editRecordedBundle(t, func(env map[string]any) {
+		sig := env["signatures"].([]any)[0].(map[string]any)
+		raw, _ := base64.StdEncoding.DecodeString(sig["sig"].(string))
+		raw[len(raw)-1] ^= 1
+		sig["sig"] = base64.StdEncoding.EncodeToString(raw)
+	})
+
+	tests := []struct {
+		name      string
+		bundle    []byte
+		digests   map[string]string
+		ownerRepo string
+		wantErr   string
+	}{
+		{"other repo", bundle, digests, "attacker/sigstore-js", "not attacker/sigstore-js"},
+		{"other asset", bundle, map[string]string{"sha512": strings.Repeat("0", 128)}, "sigstore/sigstore-js", "subject does not match"},
+		{"tampered payload", tamperedPayload, digests, "sigstore/sigstore-js", "different attestation"},
+		{"tampered signature", tamperedSignature, digests, "sigstore/sigstore-js", "signature does not match"},
+		{"not a bundle", []byte(`{}`), digests, "sigstore/sigstore-js", "no DSSE envelope"},
+	}
+
+	for _, tc := range tests {
+		err := verifyAttestationBundles([]json.RawMessage{tc.bundle}, tc.digests, tc.ownerRepo)
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("%s: verifyAttestationBundles error = %v, want %q", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestFetchAttestations(t *testing.T) {
+	bundle := readRecordedBundle(t)
+	requested := serveAttestations(t, "sigstore/sigstore-js", bundle)
+
+	bundles, err := fetchAttestations("sigstore/sigstore-js", "sha256:abcd")
+	if err != nil {
+		t.Fatalf("fetchAttestations: %v", err)
+	}
+
+	if len(bundles) != 1 || !json.Valid(bundles[0]) {
+		t.Fatalf("fetchAttestations returned %d bundles, want the recorded one", len(bundles))
+	}
+
+	if len(*requested) != 1 || (*requested)[0] != "sha256:abcd" {
+		t.Fatalf("attestations requested for %q, want [sha256:abcd]", *requested)
+	}
+
+	if _, err := fetchAttestations("other/repo", "sha256:abcd"); err == nil || !strings.Contains(err.Error(), "no attestation found") {
+		t.Fatalf("fetchAttestations error = %v, want no attestation found", err)
+	}
+}
+
+func TestDownloadAndVerifyRefusesUnattestedAsset(t *testing.T) {
+	old := options
+	t.Cleanup(func() { options = old })
+	options.cacheDir = ""
+
+	// The recorded attestation is for another artifact.
+	serveAttestations(t, "sigstore/sigstore-js", readRecordedBundle(t))
+	assets := serveReleaseFiles(t, map[string][]byte{"tool.tar.gz": []byte("release asset")})
+
+	asset := assets[0]
+	asset.attestRepo = "sigstore/sigstore-js"
+
+	var err error
+	captureStderr(t, func() {
+		_, err = downloadAndVerify(asset, 1<<20)
+	})
+	if err == nil || !strings.Contains(err.Error(), "verifying attestation") || !strings.Contains(err.Error(), "subject does not match") {
+		t.Fatalf("downloadAndVerify error = %v, want attestation subject mismatch", err)
+	}
+}
+
+func TestGithubRepoName(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/owner/repo":                     "owner/repo",
+		"git+https://github.com/owner/repo@refs/heads/main": "owner/repo",
+		"https://github.com/owner/repo.git":                 "owner/repo",
+		"owner/repo":                                        "owner/repo",
+	}
+
+	for uri, want := range tests {
+		if got := githubRepoName(uri); got != want {
+			t.Errorf("githubRepoName(%q) = %q, want %q", uri, got, want)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultCacheMaxSizeMiB int64 = 1024
+
+// defaultCacheDir returns the download cache directory, ghinst under the
+// user cache directory ($XDG_CACHE_HOME or ~/.cache on Linux).
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "ghinst")
+}
+
+// cacheable reports whether an asset with digest may be cached. Only
+// strong digests are content addresses: a sha1 collision could plant one
+// file under another's key.
+func cacheable(digest string) bool {
+	algo, _, _ := strings.Cut(strings.ToLower(digest), ":")
+	return digestAlgorithms[algo].strong
+}
+
+// cachePath returns where an asset with the given "algo:hex" digest is
+// cached under dir. Assets are stored by content, so the same bytes are
+// shared between repos, tags and install directories.
+func cachePath(dir, digest string) (string, error) {
+	algo, sum, _ := strings.Cut(strings.ToLower(digest), ":")
+	if !cacheable(digest) {
+		return "", fmt.Errorf("unsupported cache digest %q", digest)
+	}
+
+	if _, err := hex.DecodeString(sum); err != nil || sum == "" {
+		return "", fmt.Errorf("invalid cache digest %q", digest)
+	}
+
+	return filepath.Join(dir, algo, sum), nil
+}
+
+// openCachedAsset returns a temp copy of the cached bytes of asset, verified
+// against its digest, or nil when caching is off or the asset is not cached.
+// A cached file that no longer matches its digest is removed.
+func openCachedAsset(dir string, asset Asset, maxBytes int64) *os.File {
+	if dir == "" || !cacheable(asset.Digest) {
+		return nil
+	}
+
+	path, err := cachePath(dir, asset.Digest)
+	if err != nil {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+
+	defer f.Close()
+
+	tmp, err := copyToTempFile("", "ghinst-*", f, maxBytes)
+	if err != nil {
+		return nil
+	}
+
+	if err := verifyAssetDigest(asset, tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		os.Remove(path)
+		return nil
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil
+	}
+
+	// The modification time orders entries for eviction.
+	now := time.Now()
+	os.Chtimes(path, now, now)
+
+	return tmp
+}
+
+// storeCachedAsset copies the verified asset in f into the cache under dir,
+// keyed by digest, then evicts the least recently used entries until the
+// cache fits in maxSize. f is rewound afterwards.
+func storeCachedAsset(dir string, f *os.File, digest string, maxSize int64) error {
+	path, err := cachePath(dir, digest)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(filepath.Dir(path), path, f, 0644); err != nil {
+		return err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	return evictCache(dir, maxSize)
+}
+
+// cacheDirs returns the per-algorithm directories cachePath stores assets
+// in. Nothing else under dir belongs to the cache.
+func cacheDirs(dir string) []string {
+	var dirs []string
+	for algo := range digestAlgorithms {
+		dirs = append(dirs, filepath.Join(dir, algo))
+	}
+
+	sort.Strings(dirs)
+	return dirs
+}
+
+// evictCache removes cached assets, oldest first, until the total size of
+// the cache under dir is at most maxSize.
+func evictCache(dir string, maxSize int64) error {
+	type entry struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	var entries []entry
+	var total int64
+	for _, sub := range cacheDirs(dir) {
+		err := filepath.WalkDir(sub, func(path string, d os.DirEntry, err error) error {
+			if path == sub && os.IsNotExist(err) {
+				return nil
+			}
+
+			if err != nil || d.IsDir() || strings.HasPrefix(d.Name(), ".tmp-") {
+				return err
+			}
+
+			info, err := d.Info()
+			if os.IsNotExist(err) {
+				return nil
+			}
+
+			if err != nil {
+				return err
+			}
+
+			entries = append(entries, entry{path, info.Size(), info.ModTime()})
+			total += info.Size()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].modTime.Before(entries[j].modTime)
+	})
+
+	for _, e := range entries {
+		if total <= maxSize {
+			break
+		}
+
+		// A concurrent install may have evicted it already.
+		if err := os.Remove(e.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		total -= e.size
+	}
+
+	return nil
+}
+
+// cleanCache removes every cached asset. Only the directories cachePath
+// creates are removed, so a -cache-dir shared with other files is safe; dir
+// itself goes only once it is empty.
+func cleanCache(dir string) error {
+	if dir == "" {
+		return fmt.Errorf("could not determine cache dir; set -cache-dir")
+	}
+
+	if err := ensurePathNotSymlink(dir); err != nil {
+		return err
+	}
+
+	for _, sub := range cacheDirs(dir) {
+		if err := os.RemoveAll(sub); err != nil {
+			return err
+		}
+	}
+
+	os.Remove(dir)
+
+	fmt.Printf("removed cache %s\n", dir)
+	return nil
+}
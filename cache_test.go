@@ -0,0 +1,219 @@
+package main
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownloadAndVerifyUsesCache(t *testing.T) {
+	want := []byte("cached asset")
+	sum := sha256.Sum256(want)
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write(want)
+	}))
+	defer srv.Close()
+
+	old := options
+	t.Cleanup(func() { options = old })
+	options.cacheDir = t.TempDir()
+	options.cacheMax = byteSize(1 << 20)
+
+	asset := Asset{
+		Name:               "tool.tar.gz",
+		BrowserDownloadURL: srv.URL,
+		Digest:             "sha256:" + hex.EncodeToString(sum[:]),
+	}
+
+	for i := range 2 {
+		tmp, err := downloadAndVerify(asset, 1<<20)
+		if err != nil {
+			t.Fatalf("downloadAndVerify #%d: %v", i, err)
+		}
+
+		got, err := io.ReadAll(tmp)
+		tmp.Close()
+		os.Remove(tmp.Name())
+		if err != nil {
+			t.Fatalf("ReadAll #%d: %v", i, err)
+		}
+
+		if string(got) != string(want) {
+			t.Fatalf("downloadAndVerify #%d content = %q, want %q", i, got, want)
+		}
+	}
+
+	if requests != 1 {
+		t.Fatalf("server got %d requests, want 1", requests)
+	}
+
+	if _, err := os.Stat(filepath.Join(options.cacheDir, "sha256", hex.EncodeToString(sum[:]))); err != nil {
+		t.Fatalf("cache entry: %v", err)
+	}
+}
+
+func TestDownloadAndVerifySkipsCacheWithoutStrongDigest(t *testing.T) {
+	data := []byte("weakly verified asset")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	old := options
+	t.Cleanup(func() { options = old })
+	options.cacheDir = t.TempDir()
+	options.cacheMax = byteSize(1 << 20)
+
+	weak := sha1.Sum(data)
+	for _, digest := range []string{"", "sha1:" + hex.EncodeToString(weak[:])} {
+		var tmp *os.File
+		var err error
+		captureStderr(t, func() {
+			tmp, err = downloadAndVerify(Asset{Name: "tool.tar.gz", BrowserDownloadURL: srv.URL, Digest: digest}, 1<<20)
+		})
+		if err != nil {
+			t.Fatalf("downloadAndVerify(%q): %v", digest, err)
+		}
+
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+
+	entries, err := os.ReadDir(options.cacheDir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+
+	if len(entries) != 0 {
+		t.Fatalf("asset without a strong digest was cached: %v", entries)
+	}
+}
+
+func TestOpenCachedAssetDropsCorruptEntry(t *testing.T) {
+	dir := t.TempDir()
+	sum := sha256.Sum256([]byte("good"))
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+
+	path, err := cachePath(dir, digest)
+	if err != nil {
+		t.Fatalf("cachePath: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("bad"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if tmp := openCachedAsset(dir, Asset{Name: "tool", Digest: digest}, 1<<20); tmp != nil {
+		t.Fatal("openCachedAsset returned a corrupt entry")
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("corrupt entry should be removed, stat err=%v", err)
+	}
+}
+
+func TestEvictCacheRemovesOldest(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sha256"), 0755); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+
+	// Files outside the algorithm directories are not cache entries.
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), make([]byte, 100), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	now := time.Now()
+	for i, name := range []string{"old", "mid", "new"} {
+		path := filepath.Join(dir, "sha256", name)
+		if err := os.WriteFile(path, make([]byte, 10), 0644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+
+		mtime := now.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("Chtimes: %v", err)
+		}
+	}
+
+	if err := evictCache(dir, 20); err != nil {
+		t.Fatalf("evictCache: %v", err)
+	}
+
+	for name, want := range map[string]bool{"sha256/old": false, "sha256/mid": true, "sha256/new": true, "notes.txt": true} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if got := err == nil; got != want {
+			t.Errorf("%s present = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestCleanCacheKeepsOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	sum := sha256.Sum256([]byte("asset"))
+	path, err := cachePath(dir, "sha256:"+hex.EncodeToString(sum[:]))
+	if err != nil {
+		t.Fatalf("cachePath: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+
+	for _, p := range []string{path, filepath.Join(dir, "notes.txt")} {
+		if err := os.WriteFile(p, []byte("data"), 0644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+
+	captureStdout(t, func() {
+		if err := cleanCache(dir); err != nil {
+			t.Fatalf("cleanCache: %v", err)
+		}
+	})
+
+	if _, err := os.Stat(filepath.Join(dir, "sha256")); !os.IsNotExist(err) {
+		t.Fatalf("sha256 cache dir should be removed, stat err=%v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Fatalf("cleanCache removed a file it does not own: %v", err)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+
+	captureStdout(t, func() {
+		if err := cleanCache(dir); err != nil {
+			t.Fatalf("cleanCache: %v", err)
+		}
+	})
+
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("empty cache dir should be removed, stat err=%v", err)
+	}
+}
+
+func TestCachePathRejectsInvalidDigest(t *testing.T) {
+	for _, digest := range []string{"", "sha256:", "md5:abcd", "sha256:../../etc", "sha1:" + strings.Repeat("ab", 20)} {
+		if _, err := cachePath("/cache", digest); err == nil {
+			t.Errorf("cachePath(%q) expected error", digest)
+		}
+	}
+}
@@ -2,17 +2,66 @@ package main
 
 import (
 	"bytes"
+	"crypto/sha1"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
 	"fmt"
 	"hash"
 	"io"
 	"os"
 	"strings"
+
+	"golang.org/x/crypto/blake2b"
+	"lukechampine.com/blake3"
 )
 
+// digestAlgorithm is a hash usable in asset digests, named by the "algo:"
+// prefix of GitHub's digest field or by a published checksum file.
+type digestAlgorithm struct {
+	new    func() hash.Hash
+	size   int  // digest length in bytes
+	strong bool // collision resistant
+}
+
+// digestAlgorithms maps lower-case algorithm names to their implementation.
+// Weak algorithms still catch corrupted downloads, but cannot stop a
+// tampered asset, so they warn and do not satisfy -require-checksum.
+var digestAlgorithms = map[string]digestAlgorithm{
+	"sha256": {sha256.New, sha256.Size, true},
+	"sha512": {sha512.New, sha512.Size, true},
+	"blake2b": {func() hash.Hash {
+		h, _ := blake2b.New512(nil)
+		return h
+	}, blake2b.Size, true},
+	"blake3": {func() hash.Hash { return blake3.New(32, nil) }, 32, true},
+	"sha1":   {sha1.New, sha1.Size, false},
+}
+
+// digestAlgorithmBySize is the algorithm assumed for a bare hex hash in a
+// checksum file whose name does not say, by digest length in bytes.
+var digestAlgorithmBySize = map[int]string{
+	sha1.Size:   "sha1",
+	sha256.Size: "sha256",
+	sha512.Size: "sha512",
+}
+
+// sharedChecksumNames are substrings of release-wide checksum file names.
+var sharedChecksumNames = []string{"checksums", "sha256sums", "sha512sums", "sha1sums", "b2sums", "b3sums"}
+
+// maxChecksumFileSize bounds the size of published checksum files.
+const maxChecksumFileSize int64 = 1 << 20
+
+// requireChecksum makes verifyAssetDigest fail instead of warn when an asset
+// has no digest to verify against.
+var requireChecksum = false
+
 func verifyAssetDigest(asset Asset, r io.Reader) error {
 	if asset.Digest == "" {
+		if requireChecksum {
+			return fmt.Errorf("no checksum available for %s", asset.Name)
+		}
+
 		fmt.Fprintf(os.Stderr, "warning: no checksum available for %s; skipping verification\n", asset.Name)
 		return nil
 	}
@@ -22,11 +71,8 @@ func verifyAssetDigest(asset Asset, r io.Reader) error {
 		return fmt.Errorf("invalid asset digest %q", asset.Digest)
 	}
 
-	var h hash.Hash
-	switch strings.ToLower(algo) {
-	case "sha256":
-		h = sha256.New()
-	default:
+	alg, ok := digestAlgorithms[strings.ToLower(algo)]
+	if !ok {
 		return fmt.Errorf("unsupported asset digest algorithm %q", algo)
 	}
 
@@ -35,6 +81,19 @@ func verifyAssetDigest(asset Asset, r io.Reader) error {
 		return fmt.Errorf("invalid asset digest %q: %w", asset.Digest, err)
 	}
 
+	if len(want) != alg.size {
+		return fmt.Errorf("invalid asset digest %q: want %d bytes", asset.Digest, alg.size)
+	}
+
+	if !alg.strong {
+		if requireChecksum {
+			return fmt.Errorf("only a weak %s checksum is available for %s", algo, asset.Name)
+		}
+
+		fmt.Fprintf(os.Stderr, "warning: %s checksum of %s is weak; it only guards against corruption\n", algo, asset.Name)
+	}
+
+	h := alg.new()
 	if err := hashReader(r, h); err != nil {
 		return err
 	}
@@ -55,3 +114,290 @@ func hashReader(r io.Reader, h hash.Hash) error {
 
 	return nil
 }
+
+// digestsDisagree reports whether a and b are "algo:hex" digests of the
+// same algorithm with different values. Digests of different algorithms,
+// such as GitHub's sha256 and a signed sha512, cannot be compared.
+func digestsDisagree(a, b string) bool {
+	algoA, _, _ := strings.Cut(a, ":")
+	algoB, _, _ := strings.Cut(b, ":")
+	return strings.EqualFold(algoA, algoB) && !strings.EqualFold(a, b)
+}
+
+// fileDigest returns the sha256 digest of f in GitHub's "sha256:<hex>" form
+// and rewinds f for the next reader.
+func fileDigest(f *os.File) (string, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	if err := hashReader(f, h); err != nil {
+		return "", err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// releaseChecksums fetches and parses each checksum file of a release at
+// most once, however many digest and signature checks consult it.
+type releaseChecksums struct {
+	assets []Asset
+	files  map[string]checksumFile
+}
+
+// checksumFile is a fetched checksum file, or why it could not be fetched.
+type checksumFile struct {
+	data []byte
+	sums map[string]string
+	err  error
+}
+
+func newReleaseChecksums(assets []Asset) *releaseChecksums {
+	return &releaseChecksums{assets: assets, files: make(map[string]checksumFile)}
+}
+
+// fetch returns the checksum file c, downloading it on first use.
+func (rc *releaseChecksums) fetch(c Asset) checksumFile {
+	f, ok := rc.files[c.Name]
+	if !ok {
+		if f.data, f.err = fetchReleaseFile(c, maxChecksumFileSize); f.err == nil {
+			f.sums = parseChecksums(f.data, checksumFileAlgorithm(c.Name))
+		}
+
+		rc.files[c.Name] = f
+	}
+
+	return f
+}
+
+// checksumSigner finds and checks the signatures of checksum files for one
+// source of trust: -cosign-key or the keys a repo has in keys.toml.
+type checksumSigner interface {
+	// signatures returns the assets signing the file called name that the
+	// signer can check.
+	signatures(assets []Asset, name string) []Asset
+	// verifyFile checks sigs over data, the content of the file called name.
+	verifyFile(name string, data []byte, sigs []Asset) error
+}
+
+// withSignedDigest takes the digest of asset from the first checksum file
+// that signer has signatures for and that lists asset. The signatures are
+// verified before the checksums are read. It reports false when no signed
+// checksum file lists asset.
+func (rc *releaseChecksums) withSignedDigest(asset Asset, signer checksumSigner) (Asset, bool, error) {
+	for _, c := range checksumAssets(rc.assets, asset.Name) {
+		sigs := signer.signatures(rc.assets, c.Name)
+		if len(sigs) == 0 {
+			continue
+		}
+
+		f := rc.fetch(c)
+		if f.err != nil {
+			return asset, false, fmt.Errorf("reading %s: %w", c.Name, f.err)
+		}
+
+		if err := signer.verifyFile(c.Name, f.data, sigs); err != nil {
+			return asset, false, err
+		}
+
+		digest, ok := assetChecksum(f.sums, c.Name, asset.Name)
+		if !ok {
+			continue
+		}
+
+		if digestsDisagree(asset.Digest, digest) {
+			return asset, false, fmt.Errorf("signed checksum in %s does not match the digest of %s", c.Name, asset.Name)
+		}
+
+		asset.Digest = digest
+		return asset, true, nil
+	}
+
+	return asset, false, nil
+}
+
+// withPublishedDigest fills in the digest of an asset GitHub has none for
+// from a checksum file published in the same release, such as
+// <asset>.sha256, checksums.txt or SHA256SUMS. A strong digest is preferred
+// over a weak one. Checksum files that cannot be fetched are skipped with a
+// warning.
+func withPublishedDigest(asset Asset, rc *releaseChecksums) Asset {
+	if asset.Digest != "" {
+		return asset
+	}
+
+	weak := ""
+	for _, c := range checksumAssets(rc.assets, asset.Name) {
+		f := rc.fetch(c)
+		if f.err != nil {
+			fmt.Fprintf(os.Stderr, "warning: reading %s: %v\n", c.Name, f.err)
+			continue
+		}
+
+		digest, ok := assetChecksum(f.sums, c.Name, asset.Name)
+		if !ok {
+			continue
+		}
+
+		algo, _, _ := strings.Cut(digest, ":")
+		if digestAlgorithms[algo].strong {
+			asset.Digest = digest
+			return asset
+		}
+
+		if weak == "" {
+			weak = digest
+		}
+	}
+
+	asset.Digest = weak
+	return asset
+}
+
+// checksumAssets returns the release assets that may hold a checksum for
+// name: per-asset files first, then release-wide checksum lists.
+func checksumAssets(assets []Asset, name string) []Asset {
+	var own, shared []Asset
+	for _, a := range assets {
+		lower := strings.ToLower(a.Name)
+		switch {
+		case isAssetChecksumFile(a.Name, name):
+			own = append(own, a)
+		case containsAny(lower, sharedChecksumNames):
+			if !isSignatureFile(a.Name) {
+				shared = append(shared, a)
+			}
+		}
+	}
+
+	return append(own, shared...)
+}
+
+// isAssetChecksumFile reports whether checksumName is the checksum file of
+// the asset called name, e.g. tool.tar.gz.sha256 or tool.tar.gz.sha512sum.
+func isAssetChecksumFile(checksumName, name string) bool {
+	ext, ok := strings.CutPrefix(checksumName, name+".")
+	if !ok {
+		return false
+	}
+
+	_, ok = digestAlgorithms[strings.TrimSuffix(strings.ToLower(ext), "sum")]
+	return ok
+}
+
+// checksumFileAlgorithm returns the algorithm a checksum file's name implies,
+// or "" when its hashes must be told apart by length.
+func checksumFileAlgorithm(name string) string {
+	lower := strings.ToLower(name)
+	for _, a := range []struct{ word, algo string }{
+		{"sha512", "sha512"},
+		{"sha256", "sha256"},
+		{"sha1", "sha1"},
+		{"blake2", "blake2b"},
+		{"b2sum", "blake2b"},
+		{"blake3", "blake3"},
+		{"b3sum", "blake3"},
+	} {
+		if strings.Contains(lower, a.word) {
+			return a.algo
+		}
+	}
+
+	return ""
+}
+
+func containsAny(s string, substrs []string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// assetChecksum returns the digest of the asset called name in sums, parsed
+// from the checksum file called checksumName.
+func assetChecksum(sums map[string]string, checksumName, name string) (string, bool) {
+	digest, ok := sums[name]
+	if !ok && isAssetChecksumFile(checksumName, name) {
+		// A per-asset file may hold just the hash.
+		digest, ok = sums[""]
+	}
+
+	return digest, ok
+}
+
+// fetchReleaseFile downloads a small release asset, such as a checksum or
+// signature file, into memory.
+func fetchReleaseFile(a Asset, maxBytes int64) ([]byte, error) {
+	f, err := download(a.BrowserDownloadURL, a.Size, maxBytes, false)
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	return io.ReadAll(f)
+}
+
+// parseChecksums parses GNU ("<hex>  <name>", "<hex> *<name>") and BSD
+// ("SHA256 (<name>) = <hex>") checksum lines into "algo:hex" digests keyed
+// by file name. GNU lines use algo, or the algorithm matching the hash
+// length when algo is empty. A line holding only a hash is stored under the
+// empty name.
+func parseChecksums(data []byte, algo string) map[string]string {
+	sums := make(map[string]string)
+	for line := range strings.Lines(string(data)) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var name, sum, lineAlgo string
+		if tag, rest, ok := strings.Cut(line, " ("); ok && !strings.Contains(tag, " ") {
+			file, hash, ok := strings.Cut(rest, ") = ")
+			if !ok {
+				continue
+			}
+
+			name, sum = file, hash
+			lineAlgo = strings.TrimSuffix(strings.ToLower(tag), "-512")
+		} else {
+			fields := strings.Fields(line)
+			switch len(fields) {
+			case 1:
+				sum = fields[0]
+			case 2:
+				sum, name = fields[0], strings.TrimPrefix(fields[1], "*")
+			default:
+				continue
+			}
+		}
+
+		b, err := hex.DecodeString(sum)
+		if err != nil {
+			continue
+		}
+
+		if lineAlgo == "" {
+			if lineAlgo = algo; lineAlgo == "" {
+				lineAlgo = digestAlgorithmBySize[len(b)]
+			}
+		}
+
+		if alg, ok := digestAlgorithms[lineAlgo]; !ok || len(b) != alg.size {
+			continue
+		}
+
+		sums[name] = lineAlgo + ":" + strings.ToLower(sum)
+	}
+
+	return sums
+}
@@ -2,9 +2,12 @@ package main
 
 import (
 	"bytes"
+	"crypto/sha1"
 	"crypto/sha256"
 	"encoding/hex"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
@@ -126,7 +129,7 @@ func TestVerifyAssetDigestRejectsUnsupportedAlgorithm(t *testing.T) {
 
 	err = verifyAssetDigest(Asset{
 		Name:   "tool.tar.gz",
-		Digest: "sha512:" + strings.Repeat("0", 128),
+		Digest: "md5:" + strings.Repeat("0", 32),
 	}, f)
 	if err == nil {
 		t.Fatal("verifyAssetDigest expected unsupported algorithm error")
@@ -157,3 +160,167 @@ func TestVerifyAssetDigestRejectsInvalidDigestFormat(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestParseChecksums(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+	other := strings.Repeat("CD", 32)
+	data := []byte("# generated\n" +
+		sum + "  tool_linux_amd64.tar.gz\n" +
+		other + " *tool_darwin_arm64.zip\n" +
+		"SHA256 (tool_windows_amd64.zip) = " + sum + "\n" +
+		"MD5 (tool.zip) = 0123456789abcdef0123456789abcdef\n" +
+		"deadbeef  short.tar.gz\n")
+
+	got := parseChecksums(data, "")
+	want := map[string]string{
+		"tool_linux_amd64.tar.gz": "sha256:" + sum,
+		"tool_darwin_arm64.zip":   "sha256:" + strings.ToLower(other),
+		"tool_windows_amd64.zip":  "sha256:" + sum,
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("parseChecksums = %v, want %v", got, want)
+	}
+
+	for name, digest := range want {
+		if got[name] != digest {
+			t.Errorf("parseChecksums[%q] = %q, want %q", name, got[name], digest)
+		}
+	}
+
+	if got := parseChecksums([]byte(sum+"\n"), ""); got[""] != "sha256:"+sum {
+		t.Errorf("parseChecksums bare hash = %v, want it under the empty name", got)
+	}
+}
+
+func TestChecksumAssets(t *testing.T) {
+	assets := []Asset{
+		{Name: "checksums.txt"},
+		{Name: "checksums.txt.sig"},
+		{Name: "tool.tar.gz"},
+		{Name: "tool.tar.gz.sha256"},
+		{Name: "other.tar.gz.sha256"},
+		{Name: "SHA256SUMS"},
+	}
+
+	var got []string
+	for _, a := range checksumAssets(assets, "tool.tar.gz") {
+		got = append(got, a.Name)
+	}
+
+	if want := "tool.tar.gz.sha256,checksums.txt,SHA256SUMS"; strings.Join(got, ",") != want {
+		t.Fatalf("checksumAssets = %v, want %s", got, want)
+	}
+}
+
+func TestWithPublishedDigest(t *testing.T) {
+	content := []byte("asset bytes")
+	sum := sha256.Sum256(content)
+	hexSum := hex.EncodeToString(sum[:])
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/checksums.txt":
+			io.WriteString(w, strings.Repeat("0", 64)+"  other.tar.gz\n")
+		case "/tool.tar.gz.sha256":
+			io.WriteString(w, hexSum+"\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	assets := []Asset{
+		{Name: "tool.tar.gz", BrowserDownloadURL: srv.URL + "/tool.tar.gz"},
+		{Name: "checksums.txt", BrowserDownloadURL: srv.URL + "/checksums.txt"},
+		{Name: "tool.tar.gz.sha256", BrowserDownloadURL: srv.URL + "/tool.tar.gz.sha256"},
+	}
+
+	got := withPublishedDigest(assets[0], newReleaseChecksums(assets))
+	if got.Digest != "sha256:"+hexSum {
+		t.Fatalf("withPublishedDigest digest = %q, want sha256:%s", got.Digest, hexSum)
+	}
+
+	if got := withPublishedDigest(Asset{Name: "tool.tar.gz", Digest: "sha256:abc"}, newReleaseChecksums(assets)); got.Digest != "sha256:abc" {
+		t.Fatalf("withPublishedDigest replaced GitHub digest with %q", got.Digest)
+	}
+}
+
+func TestVerifyAssetDigestRequireChecksum(t *testing.T) {
+	old := requireChecksum
+	requireChecksum = true
+	t.Cleanup(func() { requireChecksum = old })
+
+	err := verifyAssetDigest(Asset{Name: "tool.tar.gz"}, strings.NewReader("hello"))
+	if err == nil || !strings.Contains(err.Error(), "no checksum available") {
+		t.Fatalf("verifyAssetDigest error = %v, want missing checksum error", err)
+	}
+}
+
+func TestVerifyAssetDigestAlgorithms(t *testing.T) {
+	content := []byte("asset bytes")
+	for algo, alg := range digestAlgorithms {
+		h := alg.new()
+		h.Write(content)
+		asset := Asset{Name: "tool.tar.gz", Digest: algo + ":" + hex.EncodeToString(h.Sum(nil))}
+
+		var err error
+		warnings := captureStderr(t, func() {
+			err = verifyAssetDigest(asset, bytes.NewReader(content))
+		})
+		if err != nil {
+			t.Errorf("verifyAssetDigest(%s): %v", algo, err)
+		}
+
+		if weak := strings.Contains(warnings, "is weak"); weak == alg.strong {
+			t.Errorf("verifyAssetDigest(%s) warnings = %q, strong = %v", algo, warnings, alg.strong)
+		}
+
+		captureStderr(t, func() {
+			err = verifyAssetDigest(asset, strings.NewReader("tampered"))
+		})
+		if err == nil {
+			t.Errorf("verifyAssetDigest(%s) accepted tampered content", algo)
+		}
+	}
+}
+
+func TestVerifyAssetDigestRejectsWeakWhenRequired(t *testing.T) {
+	old := requireChecksum
+	requireChecksum = true
+	t.Cleanup(func() { requireChecksum = old })
+
+	sum := sha1.Sum([]byte("hello"))
+	asset := Asset{Name: "tool.tar.gz", Digest: "sha1:" + hex.EncodeToString(sum[:])}
+	err := verifyAssetDigest(asset, strings.NewReader("hello"))
+	if err == nil || !strings.Contains(err.Error(), "weak sha1") {
+		t.Fatalf("verifyAssetDigest error = %v, want weak checksum error", err)
+	}
+}
+
+func TestParseChecksumsAlgorithms(t *testing.T) {
+	sha512Sum := strings.Repeat("ab", 64)
+	sha1Sum := strings.Repeat("cd", 20)
+	data := []byte(sha512Sum + "  a.tar.gz\n" +
+		sha1Sum + "  b.tar.gz\n" +
+		"BLAKE2b (c.tar.gz) = " + sha512Sum + "\n" +
+		"SHA512 (d.tar.gz) = " + sha512Sum + "\n")
+
+	got := parseChecksums(data, "")
+	want := map[string]string{
+		"a.tar.gz": "sha512:" + sha512Sum,
+		"b.tar.gz": "sha1:" + sha1Sum,
+		"c.tar.gz": "blake2b:" + sha512Sum,
+		"d.tar.gz": "sha512:" + sha512Sum,
+	}
+
+	for name, digest := range want {
+		if got[name] != digest {
+			t.Errorf("parseChecksums[%q] = %q, want %q", name, got[name], digest)
+		}
+	}
+
+	if got := parseChecksums([]byte(sha512Sum+"  e.tar.gz\n"), checksumFileAlgorithm("e_B2SUMS")); got["e.tar.gz"] != "blake2b:"+sha512Sum {
+		t.Errorf("parseChecksums with b2sums name = %v, want blake2b", got)
+	}
+}
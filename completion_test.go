@@ -41,6 +41,11 @@ func TestCompletionScripts(t *testing.T) {
 }
 
 func TestCompletionScriptsMatchCurrentFlags(t *testing.T) {
+	// registerFlags stores the real defaults, such as the user cache dir,
+	// in options.
+	old := options
+	t.Cleanup(func() { options = old })
+
 	fs := flag.NewFlagSet("ghinst", flag.ContinueOnError)
 	registerFlags(fs)
 
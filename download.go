@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Transient download failures (network errors, 429 and 5xx responses) are
+// retried up to downloadAttempts times, waiting downloadBackoff before the
+// first retry and twice as long before each next one.
+var (
+	downloadAttempts = 5
+	downloadBackoff  = 500 * time.Millisecond
+)
+
+// retryableError marks a download failure worth retrying.
+type retryableError struct {
+	err error
+}
+
+func (e retryableError) Error() string { return e.err.Error() }
+func (e retryableError) Unwrap() error { return e.err }
+
+// download fetches url into a temp file, rewound for reading. When a
+// transfer breaks off, later attempts resume with a Range request if the
+// server supports it and start over otherwise. withProgress draws a progress
+// line on a terminal; small release files such as checksums go without.
+func download(url string, expectedSize, maxBytes int64, withProgress bool) (*os.File, error) {
+	if expectedSize > 0 && expectedSize > maxBytes {
+		return nil, fmt.Errorf("asset size %d bytes exceeds limit of %d bytes", expectedSize, maxBytes)
+	}
+
+	tmp, err := os.CreateTemp("", "ghinst-*")
+	if err != nil {
+		return nil, err
+	}
+
+	var progress *downloadProgress
+	if w := progressOutput(); w != nil && withProgress {
+		progress = newDownloadProgress(w, path.Base(url), expectedSize)
+	}
+
+	var written int64
+	delay := downloadBackoff
+	for attempt := 1; ; attempt++ {
+		written, err = downloadFrom(url, tmp, written, maxBytes, progress)
+		if err == nil {
+			break
+		}
+
+		var retry retryableError
+		if !errors.As(err, &retry) || attempt >= downloadAttempts {
+			os.Remove(tmp.Name())
+			tmp.Close()
+			progress.finish()
+			if attempt > 1 {
+				err = fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+			}
+
+			return nil, err
+		}
+
+		time.Sleep(delay)
+		delay *= 2
+	}
+
+	progress.finish()
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		os.Remove(tmp.Name())
+		tmp.Close()
+		return nil, err
+	}
+
+	return tmp, nil
+}
+
+// downloadFrom fetches url into f, asking for the bytes from offset on when
+// offset is not zero, and reports the bytes received to progress, which may be
+// nil. It returns how many bytes f holds afterwards.
+func downloadFrom(url string, f *os.File, offset, maxBytes int64, progress *downloadProgress) (int64, error) {
+	req, err := newGitHubRequest(http.MethodGet, url, authScopeDownload)
+	if err != nil {
+		return offset, err
+	}
+
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return offset, retryableError{err}
+	}
+
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		// A full response, either the first one or from a server that
+		// ignores Range: start over.
+		offset = 0
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		if start := contentRangeStart(resp.Header.Get("Content-Range")); start != offset {
+			return 0, retryableError{fmt.Errorf("download resumed at byte %d, want %d", start, offset)}
+		}
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		return 0, retryableError{fmt.Errorf("download returned HTTP %d", resp.StatusCode)}
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return offset, retryableError{fmt.Errorf("download returned HTTP %d", resp.StatusCode)}
+	default:
+		return offset, fmt.Errorf("download returned HTTP %d", resp.StatusCode)
+	}
+
+	if resp.ContentLength > 0 && offset+resp.ContentLength > maxBytes {
+		return offset, fmt.Errorf("download size %d bytes exceeds limit of %d bytes", offset+resp.ContentLength, maxBytes)
+	}
+
+	if err := f.Truncate(offset); err != nil {
+		return offset, err
+	}
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return offset, err
+	}
+
+	if resp.ContentLength > 0 {
+		progress.setTotal(offset + resp.ContentLength)
+	}
+
+	progress.reset(offset)
+	n, err := io.Copy(f, progress.reader(io.LimitReader(resp.Body, maxBytes-offset+1)))
+	offset += n
+	if offset > maxBytes {
+		return offset, fmt.Errorf("size exceeds limit of %d bytes", maxBytes)
+	}
+
+	if err != nil {
+		return offset, retryableError{err}
+	}
+
+	return offset, nil
+}
+
+// contentRangeStart returns the first byte position of a Content-Range
+// header such as "bytes 100-199/200", or -1 when it cannot be parsed.
+func contentRangeStart(header string) int64 {
+	spec, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return -1
+	}
+
+	start, _, _ := strings.Cut(spec, "-")
+	n, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return -1
+	}
+
+	return n
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setTestDownloadRetries(t *testing.T, attempts int) {
+	t.Helper()
+
+	oldAttempts, oldBackoff := downloadAttempts, downloadBackoff
+	downloadAttempts, downloadBackoff = attempts, time.Millisecond
+	t.Cleanup(func() {
+		downloadAttempts, downloadBackoff = oldAttempts, oldBackoff
+	})
+}
+
+// cutConnection sends the first n bytes of data with a Content-Length for
+// all of it, then drops the connection.
+func cutConnection(t *testing.T, w http.ResponseWriter, data []byte, n int) {
+	t.Helper()
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data[:n])
+	w.(http.Flusher).Flush()
+
+	conn, _, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		t.Errorf("Hijack: %v", err)
+		return
+	}
+
+	conn.Close()
+}
+
+func readDownload(t *testing.T, tmp *os.File) []byte {
+	t.Helper()
+
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	got, err := io.ReadAll(tmp)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+
+	return got
+}
+
+func TestDownloadResumesWithRange(t *testing.T) {
+	setTestDownloadRetries(t, 3)
+	data := bytes.Repeat([]byte("0123456789"), 100)
+
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		if r.Header.Get("Range") == "" {
+			cutConnection(t, w, data, 300)
+			return
+		}
+
+		http.ServeContent(w, r, "tool.tar.gz", time.Time{}, bytes.NewReader(data))
+	}))
+	defer srv.Close()
+
+	tmp, err := download(srv.URL, int64(len(data)), 1<<20, true)
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+
+	if got := readDownload(t, tmp); !bytes.Equal(got, data) {
+		t.Fatalf("download content has %d bytes, want %d matching bytes", len(got), len(data))
+	}
+
+	if len(ranges) != 2 || ranges[1] != "bytes=300-" {
+		t.Fatalf("Range headers = %q, want [\"\" \"bytes=300-\"]", ranges)
+	}
+}
+
+func TestDownloadRestartsWhenRangeIgnored(t *testing.T) {
+	setTestDownloadRetries(t, 3)
+	data := bytes.Repeat([]byte("abcdefghij"), 100)
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			cutConnection(t, w, data, 500)
+			return
+		}
+
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	tmp, err := download(srv.URL, 0, 1<<20, true)
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+
+	if got := readDownload(t, tmp); !bytes.Equal(got, data) {
+		t.Fatalf("download content has %d bytes, want %d matching bytes", len(got), len(data))
+	}
+}
+
+func TestDownloadRetriesServerErrors(t *testing.T) {
+	setTestDownloadRetries(t, 3)
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	tmp, err := download(srv.URL, 0, 1<<20, true)
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+
+	if got := readDownload(t, tmp); string(got) != "ok" {
+		t.Fatalf("download content = %q, want ok", got)
+	}
+}
+
+func TestDownloadGivesUp(t *testing.T) {
+	setTestDownloadRetries(t, 3)
+
+	tests := []struct {
+		status       int
+		wantRequests int
+		wantErr      string
+	}{
+		{http.StatusServiceUnavailable, 3, "giving up after 3 attempts: download returned HTTP 503"},
+		{http.StatusNotFound, 1, "download returned HTTP 404"},
+	}
+
+	for _, tc := range tests {
+		requests := 0
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(tc.status)
+		}))
+
+		_, err := download(srv.URL, 0, 1<<20, true)
+		srv.Close()
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("download HTTP %d error = %v, want %q", tc.status, err, tc.wantErr)
+		}
+
+		if requests != tc.wantRequests {
+			t.Errorf("download HTTP %d made %d requests, want %d", tc.status, requests, tc.wantRequests)
+		}
+	}
+}
+
+func TestContentRangeStart(t *testing.T) {
+	tests := []struct {
+		header string
+		want   int64
+	}{
+		{"bytes 100-199/200", 100},
+		{"bytes 0-0/1", 0},
+		{"bytes */200", -1},
+		{"", -1},
+	}
+
+	for _, tc := range tests {
+		if got := contentRangeStart(tc.header); got != tc.want {
+			t.Errorf("contentRangeStart(%q) = %d, want %d", tc.header, got, tc.want)
+		}
+	}
+}
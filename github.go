@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -27,6 +29,8 @@ var (
 type Release struct {
 	TagName string  `json:"tag_name"`
 	Assets  []Asset `json:"assets"`
+
+	repo string // owner/repo the release was fetched from
 }
 
 type Asset struct {
@@ -34,6 +38,9 @@ type Asset struct {
 	BrowserDownloadURL string `json:"browser_download_url"`
 	Digest             string `json:"digest"`
 	Size               int64  `json:"size"`
+
+	signatures []Asset // signature assets to verify the download against
+	attestRepo string  // owner/repo whose attestation the download must have
 }
 
 var osAliases = map[string][]string{
@@ -43,9 +50,26 @@ var osAliases = map[string][]string{
 }
 
 var archAliases = map[string][]string{
-	"amd64": {"amd64", "x86_64"},
-	"arm64": {"arm64", "aarch64"},
-	"386":   {"386", "i386", "i686"},
+	"amd64":    {"amd64", "x86_64"},
+	"arm64":    {"arm64", "aarch64"},
+	"386":      {"386", "i386", "i686"},
+	"arm":      {"arm", "armv5", "armv6", "armv6l", "armv6hf", "armv7", "armv7l", "armv7hf", "arm-v5", "arm-v6", "arm-v7", "armhf", "armel"},
+	"riscv64":  {"riscv64", "riscv64gc"},
+	"ppc64le":  {"ppc64le", "powerpc64le"},
+	"s390x":    {"s390x"},
+	"loong64":  {"loong64", "loongarch64"},
+	"mips":     {"mips"},
+	"mipsle":   {"mipsle", "mipsel"},
+	"mips64":   {"mips64"},
+	"mips64le": {"mips64le", "mips64el"},
+}
+
+// armVersions maps 32-bit ARM asset name aliases to the GOARM level they
+// require. Debian's armhf targets ARMv7 and armel ARMv5.
+var armVersions = map[string]int{
+	"armv5": 5, "arm-v5": 5, "armel": 5,
+	"armv6": 6, "armv6l": 6, "armv6hf": 6, "arm-v6": 6,
+	"armv7": 7, "armv7l": 7, "armv7hf": 7, "arm-v7": 7, "armhf": 7,
 }
 
 type authScope int
@@ -103,37 +127,75 @@ func fetchRelease(owner, repo, tag string) (Release, error) {
 		return Release{}, err
 	}
 
+	release.repo = owner + "/" + repo
+
 	return release, nil
 }
 
+// selectAsset returns the best ranked asset for goos/goarch on this host.
+// See rankAssets for how assets are scored.
 func selectAsset(assets []Asset, goos, goarch string) (Asset, error) {
-	osPhrases, ok := osAliases[goos]
-	if !ok {
-		return Asset{}, fmt.Errorf("unsupported OS: %s", goos)
+	if err := checkPlatform(goos, goarch); err != nil {
+		return Asset{}, err
 	}
 
-	archPhrases, ok := archAliases[goarch]
-	if !ok {
-		return Asset{}, fmt.Errorf("unsupported architecture: %s", goarch)
+	ranked := rankAssets(assets, goos, goarch, hostLibc(goos))
+	if len(ranked) == 0 || !ranked[0].Eligible {
+		return Asset{}, fmt.Errorf("no asset found for %s/%s", goos, goarch)
 	}
 
-	var best Asset
-	bestFound := false
-	for _, a := range assets {
-		lower := strings.ToLower(a.Name)
-		if matchesAny(lower, osPhrases) && matchesAny(lower, archPhrases) && isArchive(lower) {
-			if !bestFound || len(a.Name) < len(best.Name) {
-				best = a
-				bestFound = true
-			}
+	return ranked[0].Asset, nil
+}
+
+func checkPlatform(goos, goarch string) error {
+	if _, ok := osAliases[goos]; !ok {
+		return fmt.Errorf("unsupported OS: %s", goos)
+	}
+
+	if _, ok := archAliases[goarch]; !ok {
+		return fmt.Errorf("unsupported architecture: %s", goarch)
+	}
+
+	return nil
+}
+
+// assetMatcher returns a matcher for asset names: a glob, or a regular
+// expression when the pattern is wrapped in slashes, e.g. /musl\.tar\.gz$/.
+func assetMatcher(pattern string) (func(string) bool, error) {
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid asset pattern %q: %w", pattern, err)
 		}
+
+		return re.MatchString, nil
 	}
 
-	if !bestFound {
-		return Asset{}, fmt.Errorf("no asset found for %s/%s", goos, goarch)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid asset pattern %q: %w", pattern, err)
+	}
+
+	return func(name string) bool {
+		ok, _ := path.Match(pattern, name)
+		return ok
+	}, nil
+}
+
+// filterAssets returns the assets whose name matches pattern.
+func filterAssets(assets []Asset, pattern string) ([]Asset, error) {
+	match, err := assetMatcher(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []Asset
+	for _, a := range assets {
+		if match(a.Name) {
+			matched = append(matched, a)
+		}
 	}
 
-	return best, nil
+	return matched, nil
 }
 
 func parseTarget(s string) (owner, repo, tag string, err error) {
@@ -164,17 +226,18 @@ func isArchive(name string) bool {
 	return false
 }
 
-func matchesAny(s string, phrases []string) bool {
-	for _, p := range phrases {
-		if strings.Contains(s, p) {
-			return true
-		}
+func getGitHub(method, endpoint string, scope authScope) (*http.Response, error) {
+	req, err := newGitHubRequest(method, endpoint, scope)
+	if err != nil {
+		return nil, err
 	}
 
-	return false
+	return httpClient.Do(req)
 }
 
-func getGitHub(method, endpoint string, scope authScope) (*http.Response, error) {
+// newGitHubRequest returns a request with the GitHub API headers, carrying
+// GITHUB_TOKEN only to hosts allowed for scope.
+func newGitHubRequest(method, endpoint string, scope authScope) (*http.Request, error) {
 	req, err := http.NewRequest(method, endpoint, nil)
 	if err != nil {
 		return nil, err
@@ -188,5 +251,5 @@ func getGitHub(method, endpoint string, scope authScope) (*http.Response, error)
 		req.Header.Set("Authorization", "Bearer "+token)
 	}
 
-	return httpClient.Do(req)
+	return req, nil
 }
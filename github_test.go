@@ -110,6 +110,38 @@ func TestSelectAsset(t *testing.T) {
 	}
 }
 
+func TestFilterAssets(t *testing.T) {
+	assets := []Asset{
+		{Name: "tool_linux_amd64.tar.gz"},
+		{Name: "tool_linux_amd64_musl.tar.gz"},
+		{Name: "tool_darwin_arm64.zip"},
+	}
+
+	got, err := filterAssets(assets, "*linux*")
+	if err != nil {
+		t.Fatalf("filterAssets: %v", err)
+	}
+
+	if len(got) != 2 || got[0].Name != assets[0].Name || got[1].Name != assets[1].Name {
+		t.Fatalf("filterAssets = %v, want the two linux assets", got)
+	}
+
+	got, err = filterAssets(assets, `/_musl\.tar\.gz$/`)
+	if err != nil {
+		t.Fatalf("filterAssets regexp: %v", err)
+	}
+
+	if len(got) != 1 || got[0].Name != "tool_linux_amd64_musl.tar.gz" {
+		t.Fatalf("filterAssets regexp = %v, want the musl asset", got)
+	}
+
+	for _, pattern := range []string{"[", "/(/"} {
+		if _, err := filterAssets(assets, pattern); err == nil {
+			t.Fatalf("filterAssets(%q) expected error for malformed pattern", pattern)
+		}
+	}
+}
+
 func TestIsArchive(t *testing.T) {
 	tests := []struct {
 		name string
@@ -10,16 +10,23 @@ tool (
 
 require github.com/ulikunitz/xz v0.5.15
 
-require github.com/klauspost/compress v1.18.4
+require (
+	github.com/BurntSushi/toml v1.4.1-0.20240526193622-a339e1f7089c
+	github.com/ProtonMail/go-crypto v1.3.0
+	github.com/klauspost/compress v1.18.4
+	golang.org/x/crypto v0.46.0
+	lukechampine.com/blake3 v1.4.1
+)
 
 require (
-	github.com/BurntSushi/toml v1.4.1-0.20240526193622-a339e1f7089c // indirect
 	github.com/Masterminds/semver v1.5.0 // indirect
 	github.com/alecthomas/kingpin v2.2.6+incompatible // indirect
 	github.com/alecthomas/template v0.0.0-20190718012654-fb15b899a751 // indirect
 	github.com/alecthomas/units v0.0.0-20210208195552-ff826a37aa15 // indirect
 	github.com/caarlos0/svu v1.12.0 // indirect
+	github.com/cloudflare/circl v1.6.1 // indirect
 	github.com/gobwas/glob v0.2.3 // indirect
+	github.com/klauspost/cpuid/v2 v2.0.9 // indirect
 	golang.org/x/exp/typeparams v0.0.0-20231108232855-2478ac86f678 // indirect
 	golang.org/x/mod v0.31.0 // indirect
 	golang.org/x/sync v0.19.0 // indirect
@@ -1,42 +1,214 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
-func download(url string, expectedSize, maxBytes int64) (*os.File, error) {
-	if expectedSize > 0 && expectedSize > maxBytes {
-		return nil, fmt.Errorf("asset size %d bytes exceeds limit of %d bytes", expectedSize, maxBytes)
+// installRecordName is the file in each version directory that records how
+// the version was installed.
+const installRecordName = ".ghinst.json"
+
+// installRecord remembers which bin links belong to a version and how the
+// asset and binaries were selected, so upgrades can install the same set again.
+type installRecord struct {
+	Links map[string]string `json:"links"` // link name in bin → binary in the version dir
+	All   bool              `json:"all,omitempty"`
+	Bins  []string          `json:"bins,omitempty"`
+	Asset string            `json:"asset,omitempty"` // asset pattern, if one was given
+}
+
+// spec returns the selection that reproduces this install, including a
+// custom link name for a single renamed binary.
+func (r installRecord) spec() installSpec {
+	spec := installSpec{assetPattern: r.Asset, bins: binarySelection{names: r.Bins, all: r.All}}
+	if len(r.Links) == 1 {
+		for linkName, bin := range r.Links {
+			if linkName != bin {
+				spec.linkName = linkName
+			}
+		}
+	}
+
+	return spec
+}
+
+func readInstallRecord(installDir string) (installRecord, bool, error) {
+	data, err := os.ReadFile(filepath.Join(installDir, installRecordName))
+	if os.IsNotExist(err) {
+		return installRecord{}, false, nil
+	}
+
+	if err != nil {
+		return installRecord{}, false, err
+	}
+
+	var rec installRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return installRecord{}, false, fmt.Errorf("reading install record: %w", err)
+	}
+
+	return rec, true, nil
+}
+
+func writeInstallRecord(installDir string, rec installRecord) error {
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := copyToTempFile(installDir, ".tmp-*", strings.NewReader(string(data)+"\n"), 0)
+	if err != nil {
+		return err
+	}
+
+	tmpName := tmp.Name()
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	if err := os.Chmod(tmpName, 0644); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	if err := os.Rename(tmpName, filepath.Join(installDir, installRecordName)); err != nil {
+		os.Remove(tmpName)
+		return err
 	}
 
-	resp, err := getGitHub(http.MethodGet, url, authScopeDownload)
+	return nil
+}
+
+// installBinaries installs each extracted binary with installBinaryAs and
+// records the resulting links. If the version directory is new, a failure
+// removes it again.
+func installBinaries(baseDir, owner, repo, tag string, bins []extractedBinary, spec installSpec) (_ []string, err error) {
+	if spec.linkName != "" && len(bins) != 1 {
+		return nil, fmt.Errorf("cannot link %d binaries as %q; -as needs a single binary", len(bins), spec.linkName)
+	}
+
+	installDir, _, err := managedInstallDir(baseDir, owner, repo, tag)
 	if err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
+	installDirPreExisted := true
+	if _, statErr := os.Lstat(installDir); os.IsNotExist(statErr) {
+		installDirPreExisted = false
+	} else if statErr != nil {
+		return nil, statErr
+	}
+
+	defer func() {
+		if err != nil && !installDirPreExisted {
+			os.RemoveAll(installDir)
+		}
+	}()
+
+	rec := installRecord{
+		Links: map[string]string{},
+		All:   spec.bins.all,
+		Bins:  spec.bins.names,
+		Asset: spec.assetPattern,
+	}
+	linkPaths := make([]string, 0, len(bins))
+	for _, b := range bins {
+		linkName := b.name
+		if spec.linkName != "" {
+			linkName = spec.linkName
+		}
+
+		linkPath, err := installBinaryAs(baseDir, owner, repo, tag, b.name, linkName, b.file)
+		if err != nil {
+			return nil, err
+		}
+
+		rec.Links[filepath.Base(linkPath)] = b.name
+		linkPaths = append(linkPaths, linkPath)
+	}
+
+	if err := writeInstallRecord(installDir, rec); err != nil {
+		return nil, err
+	}
+
+	return linkPaths, nil
+}
+
+// writeBinaries writes each extracted binary into dir, named linkName when
+// set, outside the managed layout: nothing is recorded or symlinked.
+func writeBinaries(dir string, bins []extractedBinary, linkName string) ([]string, error) {
+	if linkName != "" && len(bins) != 1 {
+		return nil, fmt.Errorf("cannot write %d binaries as %q; -as needs a single binary", len(bins), linkName)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(bins))
+	for _, b := range bins {
+		name := b.name
+		if linkName != "" {
+			name = linkName
+		}
+
+		path := filepath.Join(dir, name)
+		if err := writeFileAtomic(dir, path, b.file, 0755); err != nil {
+			return nil, err
+		}
+
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
 
-	if resp.ContentLength > 0 && resp.ContentLength > maxBytes {
-		return nil, fmt.Errorf("download size %d bytes exceeds limit of %d bytes", resp.ContentLength, maxBytes)
+// writeFileAtomic copies src to path with the given mode by renaming a temp
+// file in dir over it.
+func writeFileAtomic(dir, path string, src io.Reader, mode os.FileMode) error {
+	tmp, err := copyToTempFile(dir, ".tmp-*", src, 0)
+	if err != nil {
+		return err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("download returned HTTP %d", resp.StatusCode)
+	tmpName := tmp.Name()
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return err
 	}
 
-	return copyToTempFile("", "ghinst-*", resp.Body, maxBytes)
+	return nil
 }
 
 // installBinary places the binary under <baseDir>/ghinst/owner/repo@tag/
 // and symlinks it into <baseDir>/bin/.
-func installBinary(baseDir, owner, repo, tag, binName string, src io.Reader) (_ string, err error) {
+func installBinary(baseDir, owner, repo, tag, binName string, src io.Reader) (string, error) {
+	return installBinaryAs(baseDir, owner, repo, tag, binName, binName, src)
+}
+
+// installBinaryAs is installBinary with the symlink in <baseDir>/bin/ named
+// linkName instead of binName.
+func installBinaryAs(baseDir, owner, repo, tag, binName, linkName string, src io.Reader) (_ string, err error) {
 	installDir, _, err := managedInstallDir(baseDir, owner, repo, tag)
 	if err != nil {
 		return "", err
@@ -93,7 +265,46 @@ func installBinary(baseDir, owner, repo, tag, binName string, src io.Reader) (_
 		return "", err
 	}
 
-	linkDir, linkPath, err := managedLinkPath(baseDir, binName)
+	return replaceSymlink(baseDir, linkName, binPath)
+}
+
+// linkLocks holds a *sync.Mutex per bin link path, so concurrent installs
+// that want the same link replace it one at a time.
+var linkLocks sync.Map
+
+func lockLink(linkPath string) (unlock func()) {
+	mu, _ := linkLocks.LoadOrStore(linkPath, new(sync.Mutex))
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+// linkedRepo returns the "owner/repo" whose install dir holds target, or ""
+// when target is outside the managed root.
+func linkedRepo(baseDir, target string) string {
+	rel, err := filepath.Rel(managedGhinstRoot(baseDir), filepath.Dir(target))
+	if err != nil {
+		return ""
+	}
+
+	owner, dir, ok := strings.Cut(filepath.ToSlash(rel), "/")
+	if !ok || owner == ".." || strings.Contains(dir, "/") {
+		return ""
+	}
+
+	repo, _, ok := installDirParts(dir)
+	if !ok {
+		return ""
+	}
+
+	return owner + "/" + repo
+}
+
+// replaceSymlink atomically points <baseDir>/bin/<linkName> at target by
+// renaming a freshly created temp link over it. Only an existing symlink is
+// ever replaced, and only when it belongs to the same repo or to none:
+// two repos that ship the same binary name must not take turns owning it.
+func replaceSymlink(baseDir, linkName, target string) (string, error) {
+	linkDir, linkPath, err := managedLinkPath(baseDir, linkName)
 	if err != nil {
 		return "", err
 	}
@@ -106,15 +317,26 @@ func installBinary(baseDir, owner, repo, tag, binName string, src io.Reader) (_
 		return "", err
 	}
 
+	defer lockLink(linkPath)()
+
 	if info, err := os.Lstat(linkPath); err == nil {
 		if info.Mode()&os.ModeSymlink == 0 {
 			return "", fmt.Errorf("refusing to replace non-symlink %s", linkPath)
 		}
+
+		current, err := os.Readlink(linkPath)
+		if err != nil {
+			return "", err
+		}
+
+		if owner := linkedRepo(baseDir, current); owner != "" && owner != linkedRepo(baseDir, target) {
+			return "", fmt.Errorf("%s is linked to %s; uninstall it or pick another name with -as", linkPath, owner)
+		}
 	} else if !os.IsNotExist(err) {
 		return "", err
 	}
 
-	tmpLink, err := os.CreateTemp(linkDir, "."+binName+".tmp-*")
+	tmpLink, err := os.CreateTemp(linkDir, "."+linkName+".tmp-*")
 	if err != nil {
 		return "", err
 	}
@@ -128,7 +350,7 @@ func installBinary(baseDir, owner, repo, tag, binName string, src io.Reader) (_
 	}
 	defer os.Remove(tmpLinkPath)
 
-	if err := os.Symlink(binPath, tmpLinkPath); err != nil {
+	if err := os.Symlink(target, tmpLinkPath); err != nil {
 		return "", err
 	}
 
@@ -153,28 +375,54 @@ func defaultBaseDir() string {
 }
 
 func listInstalled(baseDir string) error {
-	active, err := activeInstallDirs(baseDir)
+	versions, err := installedVersions(baseDir)
 	if err != nil {
 		return err
 	}
 
+	for _, v := range versions {
+		marker := " "
+		if v.Active {
+			marker = "*"
+		}
+
+		fmt.Printf("%s %s/%s %s\n", marker, v.Owner, v.Repo, v.Tag)
+	}
+
+	return nil
+}
+
+// installedVersion is a single owner/repo@tag directory under the managed root.
+type installedVersion struct {
+	Owner  string
+	Repo   string
+	Tag    string
+	Dir    string
+	Active bool
+}
+
+// installedVersions returns every installed version, ordered by owner, then
+// repo, then tag (newest first), marking the ones currently linked from bin.
+func installedVersions(baseDir string) ([]installedVersion, error) {
+	active, err := activeInstallDirs(baseDir)
+	if err != nil {
+		return nil, err
+	}
+
 	ghinstDir := managedGhinstRoot(baseDir)
 	if err := ensurePathNotSymlink(ghinstDir); err != nil {
-		return err
+		return nil, err
 	}
 
 	owners, err := readDirIfExists(ghinstDir)
 	if err != nil {
-		return err
-	}
-
-	if owners == nil {
-		return nil
+		return nil, err
 	}
 
+	var versions []installedVersion
 	for _, owner := range owners {
 		if owner.Type()&os.ModeSymlink != 0 {
-			return fmt.Errorf("refusing to use symlinked path %s", filepath.Join(ghinstDir, owner.Name()))
+			return nil, fmt.Errorf("refusing to use symlinked path %s", filepath.Join(ghinstDir, owner.Name()))
 		}
 
 		if !owner.IsDir() {
@@ -183,12 +431,12 @@ func listInstalled(baseDir string) error {
 
 		ownerDir, err := managedOwnerDir(baseDir, owner.Name())
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		entries, err := os.ReadDir(ownerDir)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		sort.Slice(entries, func(i, j int) bool {
@@ -202,7 +450,7 @@ func listInstalled(baseDir string) error {
 		})
 		for _, e := range entries {
 			if e.Type()&os.ModeSymlink != 0 {
-				return fmt.Errorf("refusing to use symlinked path %s", filepath.Join(ownerDir, e.Name()))
+				return nil, fmt.Errorf("refusing to use symlinked path %s", filepath.Join(ownerDir, e.Name()))
 			}
 
 			if !e.IsDir() {
@@ -214,16 +462,40 @@ func listInstalled(baseDir string) error {
 				continue
 			}
 
-			marker := " "
-			if active[filepath.Join(ownerDir, e.Name())] {
-				marker = "*"
-			}
+			dir := filepath.Join(ownerDir, e.Name())
+			versions = append(versions, installedVersion{
+				Owner:  owner.Name(),
+				Repo:   repo,
+				Tag:    decodeTagFromPathComponent(encodedTag),
+				Dir:    dir,
+				Active: active[dir],
+			})
+		}
+	}
+
+	return versions, nil
+}
 
-			fmt.Printf("%s %s/%s %s\n", marker, owner.Name(), repo, decodeTagFromPathComponent(encodedTag))
+// activeVersions returns the linked version of each installed owner/repo.
+func activeVersions(baseDir string) ([]installedVersion, error) {
+	versions, err := installedVersions(baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var current []installedVersion
+	seen := map[string]bool{}
+	for _, v := range versions {
+		key := v.Owner + "/" + v.Repo
+		if !v.Active || seen[key] {
+			continue
 		}
+
+		seen[key] = true
+		current = append(current, v)
 	}
 
-	return nil
+	return current, nil
 }
 
 // purge removes all but the currently linked version of owner/repo.
@@ -300,6 +572,217 @@ func purge(baseDir, owner, repo string) error {
 	return nil
 }
 
+// uninstall removes every installed version of owner/repo, the bin symlinks
+// that point into them and the owner directory once it is empty.
+func uninstall(baseDir, owner, repo string) error {
+	if err := validateTargetParts(owner, repo); err != nil {
+		return err
+	}
+
+	ownerDir, err := managedOwnerDir(baseDir, owner)
+	if err != nil {
+		return err
+	}
+
+	entries, err := readDirIfExists(ownerDir)
+	if err != nil {
+		return err
+	}
+
+	versions, err := repoVersions(ownerDir, repo, entries)
+	if err != nil {
+		return err
+	}
+
+	if len(versions) == 0 {
+		return fmt.Errorf("%s/%s is not installed", owner, repo)
+	}
+
+	dirs := make(map[string]bool, len(versions))
+	for _, v := range versions {
+		dir, err := managedJoin(ownerDir, v.Name())
+		if err != nil {
+			return err
+		}
+
+		if err := ensurePathNotSymlink(dir); err != nil {
+			return err
+		}
+
+		dirs[dir] = true
+	}
+
+	links, err := repoLinks(baseDir, ownerDir, repo)
+	if err != nil {
+		return err
+	}
+
+	for linkPath := range links {
+		if err := os.Remove(linkPath); err != nil {
+			return err
+		}
+	}
+
+	for dir := range dirs {
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+	}
+
+	remaining, err := os.ReadDir(ownerDir)
+	if err != nil {
+		return err
+	}
+
+	if len(remaining) == 0 {
+		if err := os.Remove(ownerDir); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("uninstalled %s/%s\n", owner, repo)
+	return nil
+}
+
+// useVersion points the bin symlinks of owner/repo at an already installed
+// version. Links keep their names; links to binaries the version does not
+// ship are removed.
+func useVersion(baseDir, owner, repo, tag string) error {
+	installDir, _, err := managedInstallDir(baseDir, owner, repo, tag)
+	if err != nil {
+		return err
+	}
+
+	healthy, err := isHealthyInstallDir(installDir)
+	if err != nil {
+		return err
+	}
+
+	if !healthy {
+		return fmt.Errorf("%s/%s@%s is not installed", owner, repo, tag)
+	}
+
+	bins, err := installedBinaries(installDir)
+	if err != nil {
+		return err
+	}
+
+	links, err := repoLinks(baseDir, filepath.Dir(installDir), repo)
+	if err != nil {
+		return err
+	}
+
+	// Link names come from the version's install record when it has one, so
+	// custom -as names survive; otherwise reuse the names of the current links.
+	linkNames := map[string]string{}
+	for linkPath, target := range links {
+		linkNames[filepath.Base(target)] = filepath.Base(linkPath)
+	}
+
+	rec, ok, err := readInstallRecord(installDir)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		for linkName, bin := range rec.Links {
+			linkNames[bin] = linkName
+		}
+	}
+
+	keep := map[string]bool{}
+	for _, bin := range bins {
+		linkName := linkNames[bin]
+		if linkName == "" {
+			linkName = bin
+		}
+
+		linkPath, err := replaceSymlink(baseDir, linkName, filepath.Join(installDir, bin))
+		if err != nil {
+			return err
+		}
+
+		keep[linkPath] = true
+		fmt.Printf("using %s (%s) → %s\n", repo, tag, linkPath)
+	}
+
+	for linkPath := range links {
+		if keep[linkPath] {
+			continue
+		}
+
+		if err := os.Remove(linkPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// installedBinaries returns the names of the executables in an install dir.
+func installedBinaries(installDir string) ([]string, error) {
+	entries, err := os.ReadDir(installDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		fi, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		if fi.Mode().IsRegular() && fi.Mode()&0111 != 0 {
+			names = append(names, entry.Name())
+		}
+	}
+
+	return names, nil
+}
+
+// repoLinks returns the bin symlinks that point into any installed version of
+// repo under ownerDir, mapped to their targets.
+func repoLinks(baseDir, ownerDir, repo string) (map[string]string, error) {
+	binDir := managedBinDir(baseDir)
+	if err := ensurePathNotSymlink(binDir); err != nil {
+		return nil, err
+	}
+
+	entries, err := readDirIfExists(binDir)
+	if err != nil {
+		return nil, err
+	}
+
+	links := map[string]string{}
+	for _, e := range entries {
+		if e.Type()&os.ModeSymlink == 0 {
+			continue
+		}
+
+		linkPath := filepath.Join(binDir, e.Name())
+		target, err := os.Readlink(linkPath)
+		if err != nil {
+			continue
+		}
+
+		versionDir := filepath.Dir(target)
+		if filepath.Dir(versionDir) != ownerDir {
+			continue
+		}
+
+		if name, _, ok := installDirParts(filepath.Base(versionDir)); ok && name == repo {
+			links[linkPath] = target
+		}
+	}
+
+	return links, nil
+}
+
 func copyToTempFile(dir, pattern string, r io.Reader, maxBytes int64) (*os.File, error) {
 	tmp, err := os.CreateTemp(dir, pattern)
 	if err != nil {
@@ -61,7 +61,7 @@ func TestDownload(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	tmp, err := download(srv.URL, int64(len(want)), 1<<20)
+	tmp, err := download(srv.URL, int64(len(want)), 1<<20, true)
 	if err != nil {
 		t.Fatalf("download: unexpected error: %v", err)
 	}
@@ -95,7 +95,7 @@ func TestDownloadAddsAuthorizationForAllowedGitHubHosts(t *testing.T) {
 		}, nil
 	}))
 
-	tmp, err := download("https://github.com/owner/repo/releases/download/v1.2.3/tool.tar.gz", 2, 1<<20)
+	tmp, err := download("https://github.com/owner/repo/releases/download/v1.2.3/tool.tar.gz", 2, 1<<20, true)
 	if err != nil {
 		t.Fatalf("download: unexpected error: %v", err)
 	}
@@ -127,7 +127,7 @@ func TestDownloadSkipsAuthorizationForUntrustedHosts(t *testing.T) {
 				}, nil
 			}))
 
-			tmp, err := download(rawURL, 2, 1<<20)
+			tmp, err := download(rawURL, 2, 1<<20, true)
 			if err != nil {
 				t.Fatalf("download: unexpected error: %v", err)
 			}
@@ -145,7 +145,7 @@ func TestDownloadTimeout(t *testing.T) {
 			return nil, req.Context().Err()
 		}))
 
-		_, err := download("http://example.com/dl", 0, 1<<20)
+		_, err := download("http://example.com/dl", 0, 1<<20, true)
 		if err == nil {
 			t.Fatal("download expected timeout error")
 		}
@@ -162,7 +162,7 @@ func TestDownloadRejectsDeclaredAssetSizeOverLimit(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	_, err := download(srv.URL, 10, 5)
+	_, err := download(srv.URL, 10, 5, true)
 	if err == nil {
 		t.Fatal("download expected error for oversized asset metadata")
 	}
@@ -178,7 +178,7 @@ func TestDownloadRejectsResponseBodyOverLimit(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	_, err := download(srv.URL, 0, 5)
+	_, err := download(srv.URL, 0, 5, true)
 	if err == nil {
 		t.Fatal("download expected error for oversized response body")
 	}
@@ -877,3 +877,287 @@ func TestActiveInstallDirs(t *testing.T) {
 		t.Fatalf("activeInstallDirs missing %q", installDir)
 	}
 }
+
+func TestUninstall(t *testing.T) {
+	tmpDir := t.TempDir()
+	ownerDir := filepath.Join(tmpDir, "ghinst", "owner")
+	binDir := filepath.Join(tmpDir, "bin")
+
+	v1 := filepath.Join(ownerDir, "repo@v1.0.0")
+	v2 := filepath.Join(ownerDir, "repo@v2.0.0")
+	for _, d := range []string{v1, v2, binDir} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"repo", "repo-helper"} {
+		binPath := filepath.Join(v2, name)
+		if err := os.WriteFile(binPath, []byte("bin"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Symlink(binPath, filepath.Join(binDir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A link into a repo whose name shares the prefix must survive.
+	other := filepath.Join(tmpDir, "ghinst", "owner", "repo2@v1.0.0")
+	if err := os.MkdirAll(other, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	otherBin := filepath.Join(other, "repo2")
+	if err := os.WriteFile(otherBin, []byte("bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink(otherBin, filepath.Join(binDir, "repo2")); err != nil {
+		t.Fatal(err)
+	}
+
+	out := captureStdout(t, func() {
+		if err := uninstall(tmpDir, "owner", "repo"); err != nil {
+			t.Fatalf("uninstall: %v", err)
+		}
+	})
+
+	if out != "uninstalled owner/repo\n" {
+		t.Fatalf("uninstall output = %q", out)
+	}
+
+	for _, path := range []string{v1, v2, filepath.Join(binDir, "repo"), filepath.Join(binDir, "repo-helper")} {
+		if _, err := os.Lstat(path); !os.IsNotExist(err) {
+			t.Errorf("%s should have been removed, lstat err=%v", path, err)
+		}
+	}
+
+	if _, err := os.Lstat(filepath.Join(binDir, "repo2")); err != nil {
+		t.Errorf("repo2 link should remain: %v", err)
+	}
+
+	if _, err := os.Stat(ownerDir); err != nil {
+		t.Errorf("owner dir with other repos should remain: %v", err)
+	}
+}
+
+func TestUninstallRemovesEmptyOwnerDir(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	src, err := writeTempFile(bytes.NewReader([]byte("binary content")), 1<<20)
+	if err != nil {
+		t.Fatalf("writeTempFile: %v", err)
+	}
+
+	defer os.Remove(src.Name())
+	defer src.Close()
+
+	if _, err := installBinary(tmpDir, "owner", "repo", "v1.0.0", "tool", src); err != nil {
+		t.Fatalf("installBinary: %v", err)
+	}
+
+	captureStdout(t, func() {
+		if err := uninstall(tmpDir, "owner", "repo"); err != nil {
+			t.Fatalf("uninstall: %v", err)
+		}
+	})
+
+	if _, err := os.Stat(filepath.Join(tmpDir, "ghinst", "owner")); !os.IsNotExist(err) {
+		t.Fatalf("empty owner dir should be removed, stat err=%v", err)
+	}
+}
+
+func TestUninstallNotInstalled(t *testing.T) {
+	err := uninstall(t.TempDir(), "owner", "repo")
+	if err == nil || !strings.Contains(err.Error(), "owner/repo is not installed") {
+		t.Fatalf("uninstall error = %v, want not installed", err)
+	}
+}
+
+func TestUninstallRejectsSymlinkedOwnerDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	external := t.TempDir()
+
+	versionDir := filepath.Join(external, "repo@"+encodeTagForPath("v1.0.0"))
+	if err := os.MkdirAll(versionDir, 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(tmpDir, "ghinst"), 0755); err != nil {
+		t.Fatalf("MkdirAll ghinst root: %v", err)
+	}
+
+	if err := os.Symlink(external, filepath.Join(tmpDir, "ghinst", "owner")); err != nil {
+		t.Fatalf("Symlink owner dir: %v", err)
+	}
+
+	err := uninstall(tmpDir, "owner", "repo")
+	if err == nil || !strings.Contains(err.Error(), "refusing to use symlinked path") {
+		t.Fatalf("uninstall error = %v, want symlink refusal", err)
+	}
+
+	if _, err := os.Stat(versionDir); err != nil {
+		t.Fatalf("external dir should remain: %v", err)
+	}
+}
+
+func TestUseVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	for _, tag := range []string{"v1.0.0", "v2.0.0"} {
+		src, err := writeTempFile(bytes.NewReader([]byte(tag)), 1<<20)
+		if err != nil {
+			t.Fatalf("writeTempFile: %v", err)
+		}
+
+		defer os.Remove(src.Name())
+		defer src.Close()
+
+		if _, err := installBinary(tmpDir, "owner", "repo", tag, "tool", src); err != nil {
+			t.Fatalf("installBinary %s: %v", tag, err)
+		}
+	}
+
+	out := captureStdout(t, func() {
+		if err := useVersion(tmpDir, "owner", "repo", "v1.0.0"); err != nil {
+			t.Fatalf("useVersion: %v", err)
+		}
+	})
+
+	linkPath := filepath.Join(tmpDir, "bin", "tool")
+	if want := "using repo (v1.0.0) → " + linkPath + "\n"; out != want {
+		t.Fatalf("useVersion output = %q, want %q", out, want)
+	}
+
+	got, err := os.ReadFile(linkPath)
+	if err != nil {
+		t.Fatalf("ReadFile via link: %v", err)
+	}
+
+	if string(got) != "v1.0.0" {
+		t.Fatalf("link resolves to %q, want v1.0.0 binary", got)
+	}
+
+	tmpLinks, err := filepath.Glob(filepath.Join(tmpDir, "bin", ".tool.tmp-*"))
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+
+	if len(tmpLinks) != 0 {
+		t.Fatalf("temporary links left behind: %v", tmpLinks)
+	}
+}
+
+func TestUseVersionRemovesLinksMissingFromVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+	ownerDir := filepath.Join(tmpDir, "ghinst", "owner")
+	binDir := filepath.Join(tmpDir, "bin")
+
+	v1 := filepath.Join(ownerDir, "repo@"+encodeTagForPath("v1.0.0"))
+	v2 := filepath.Join(ownerDir, "repo@"+encodeTagForPath("v2.0.0"))
+	for _, d := range []string{v1, v2, binDir} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(v1, "tool"), []byte("v1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"tool", "extra"} {
+		if err := os.WriteFile(filepath.Join(v2, name), []byte("v2"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Symlink(filepath.Join(v2, name), filepath.Join(binDir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	captureStdout(t, func() {
+		if err := useVersion(tmpDir, "owner", "repo", "v1.0.0"); err != nil {
+			t.Fatalf("useVersion: %v", err)
+		}
+	})
+
+	target, err := os.Readlink(filepath.Join(binDir, "tool"))
+	if err != nil || target != filepath.Join(v1, "tool") {
+		t.Fatalf("tool link target = %q (err %v), want %q", target, err, filepath.Join(v1, "tool"))
+	}
+
+	if _, err := os.Lstat(filepath.Join(binDir, "extra")); !os.IsNotExist(err) {
+		t.Fatalf("extra link should be removed, lstat err=%v", err)
+	}
+}
+
+func TestUseVersionNotInstalled(t *testing.T) {
+	err := useVersion(t.TempDir(), "owner", "repo", "v9.9.9")
+	if err == nil || !strings.Contains(err.Error(), "owner/repo@v9.9.9 is not installed") {
+		t.Fatalf("useVersion error = %v, want not installed", err)
+	}
+}
+
+func TestInstallBinariesRecordsLinks(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	var bins []extractedBinary
+	for _, name := range []string{"server", "cli"} {
+		f, err := writeTempFile(bytes.NewReader([]byte(name)), 1<<20)
+		if err != nil {
+			t.Fatalf("writeTempFile: %v", err)
+		}
+
+		bins = append(bins, extractedBinary{name, f})
+	}
+
+	defer closeExtracted(bins)
+
+	linkPaths, err := installBinaries(tmpDir, "owner", "repo", "v1.0.0", bins, installSpec{bins: binarySelection{all: true}})
+	if err != nil {
+		t.Fatalf("installBinaries: %v", err)
+	}
+
+	if len(linkPaths) != 2 {
+		t.Fatalf("linkPaths = %v, want 2 links", linkPaths)
+	}
+
+	installDir := filepath.Join(tmpDir, "ghinst", "owner", "repo@"+encodeTagForPath("v1.0.0"))
+	rec, ok, err := readInstallRecord(installDir)
+	if err != nil || !ok {
+		t.Fatalf("readInstallRecord ok=%v err=%v", ok, err)
+	}
+
+	if !rec.All || rec.Links["server"] != "server" || rec.Links["cli"] != "cli" {
+		t.Fatalf("install record = %+v", rec)
+	}
+
+	bin, err := installedBinaries(installDir)
+	if err != nil {
+		t.Fatalf("installedBinaries: %v", err)
+	}
+
+	if len(bin) != 2 {
+		t.Fatalf("installedBinaries = %v, record file must not count as a binary", bin)
+	}
+}
+
+func TestInstallBinariesRejectsLinkNameForSeveralBinaries(t *testing.T) {
+	var bins []extractedBinary
+	for _, name := range []string{"server", "cli"} {
+		f, err := writeTempFile(bytes.NewReader([]byte(name)), 1<<20)
+		if err != nil {
+			t.Fatalf("writeTempFile: %v", err)
+		}
+
+		bins = append(bins, extractedBinary{name, f})
+	}
+
+	defer closeExtracted(bins)
+
+	_, err := installBinaries(t.TempDir(), "owner", "repo", "v1.0.0", bins, installSpec{linkName: "tool"})
+	if err == nil || !strings.Contains(err.Error(), "-as needs a single binary") {
+		t.Fatalf("installBinaries error = %v, want single binary error", err)
+	}
+}
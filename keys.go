@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	pgperrors "github.com/ProtonMail/go-crypto/openpgp/errors"
+)
+
+// keysFile is a keys.toml file of per-repo trusted signing keys:
+//
+//	[[repo]]
+//	name = "jedisct1/minisign"
+//	minisign = ["RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3"]
+//
+//	[[repo]]
+//	name = "owner/tool"
+//	gpg = ["maintainer.asc"] # armored public keys, relative to keys.toml
+type keysFile struct {
+	Repos []keysFileRepo `toml:"repo"`
+}
+
+type keysFileRepo struct {
+	Name     string   `toml:"name"`
+	Minisign []string `toml:"minisign"`
+	GPG      []string `toml:"gpg"`
+}
+
+// repoKeys are the keys a repo's checksum files must be signed with.
+type repoKeys struct {
+	minisign []minisignKey
+	gpg      openpgp.EntityList
+}
+
+// checksumSignatureSuffixes are the minisign and OpenPGP signatures a
+// checksum file may be published with.
+var checksumSignatureSuffixes = []string{".minisig", ".asc", ".gpg"}
+
+// trustedKeys maps lower-case "owner/repo" to the keys configured for it.
+var trustedKeys map[string]*repoKeys
+
+// defaultKeysPath returns keys.toml under the user config directory
+// ($XDG_CONFIG_HOME/ghinst or ~/.config/ghinst on Linux).
+func defaultKeysPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "ghinst", "keys.toml")
+}
+
+// loadTrustedKeys reads the keys file at path. A missing file is only an
+// error when mustExist is set.
+func loadTrustedKeys(path string, mustExist bool) (map[string]*repoKeys, error) {
+	var f keysFile
+	md, err := toml.DecodeFile(path, &f)
+	if os.IsNotExist(err) && !mustExist {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("reading keys: %w", err)
+	}
+
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		names := make([]string, len(undecoded))
+		for i, k := range undecoded {
+			names[i] = k.String()
+		}
+
+		return nil, fmt.Errorf("reading keys: unknown keys %s", strings.Join(names, ", "))
+	}
+
+	keys := make(map[string]*repoKeys)
+	for i, r := range f.Repos {
+		owner, repo, tag, err := parseTarget(r.Name)
+		if err == nil && tag != "" {
+			err = fmt.Errorf("unexpected version in %q", r.Name)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("keys repo %d: %w", i+1, err)
+		}
+
+		name := strings.ToLower(owner + "/" + repo)
+		if keys[name] != nil {
+			return nil, fmt.Errorf("keys file lists %s more than once", name)
+		}
+
+		k := &repoKeys{}
+		for _, s := range r.Minisign {
+			mk, err := parseMinisignKey(s)
+			if err != nil {
+				return nil, fmt.Errorf("keys for %s: %w", name, err)
+			}
+
+			k.minisign = append(k.minisign, mk)
+		}
+
+		for _, p := range r.GPG {
+			if !filepath.IsAbs(p) {
+				p = filepath.Join(filepath.Dir(path), p)
+			}
+
+			entities, err := readGPGKeys(p)
+			if err != nil {
+				return nil, fmt.Errorf("keys for %s: %w", name, err)
+			}
+
+			k.gpg = append(k.gpg, entities...)
+		}
+
+		if len(k.minisign) == 0 && len(k.gpg) == 0 {
+			return nil, fmt.Errorf("keys for %s: no minisign or gpg key", name)
+		}
+
+		keys[name] = k
+	}
+
+	return keys, nil
+}
+
+// readGPGKeys reads an armored or binary OpenPGP public key file.
+func readGPGKeys(path string) (openpgp.EntityList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	if err != nil {
+		entities, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return entities, nil
+}
+
+// withSignedChecksum takes the digest of asset from a checksum file signed
+// with a key configured for repo, such as SHA256SUMS next to
+// SHA256SUMS.minisig or SHA256SUMS.asc. The signature is verified before
+// the checksum file is read, and a digest GitHub reports is not trusted on
+// its own. Repos without configured keys are left alone.
+func withSignedChecksum(asset Asset, repo string, rc *releaseChecksums) (Asset, error) {
+	keys := trustedKeys[strings.ToLower(repo)]
+	if keys == nil {
+		return asset, nil
+	}
+
+	asset, ok, err := rc.withSignedDigest(asset, keys)
+	if err == nil && !ok {
+		err = fmt.Errorf("no checksum file signed by a trusted key of %s lists %s", repo, asset.Name)
+	}
+
+	return asset, err
+}
+
+// signatures returns the signatures of the release file called name that
+// k has keys to check.
+func (k *repoKeys) signatures(assets []Asset, name string) []Asset {
+	var sigs []Asset
+	for _, a := range assets {
+		rest, ok := strings.CutPrefix(a.Name, name)
+		if !ok {
+			continue
+		}
+
+		switch strings.ToLower(rest) {
+		case ".minisig":
+			if len(k.minisign) > 0 {
+				sigs = append(sigs, a)
+			}
+		case ".asc", ".gpg":
+			if len(k.gpg) > 0 {
+				sigs = append(sigs, a)
+			}
+		}
+	}
+
+	return sigs
+}
+
+// verifyFile checks every signature in sigs over data, the content of the
+// release file called name.
+func (k *repoKeys) verifyFile(name string, data []byte, sigs []Asset) error {
+	for _, s := range sigs {
+		sig, err := fetchReleaseFile(s, maxSignatureFileSize)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", s.Name, err)
+		}
+
+		if err := k.verify(data, s.Name, sig); err != nil {
+			return fmt.Errorf("%s: %w", s.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// verify checks the signature file called sigName over data.
+func (k *repoKeys) verify(data []byte, sigName string, sig []byte) error {
+	switch strings.ToLower(filepath.Ext(sigName)) {
+	case ".minisig":
+		return verifyMinisign(k.minisign, data, sig)
+	case ".asc":
+		_, err := openpgp.CheckArmoredDetachedSignature(k.gpg, bytes.NewReader(data), bytes.NewReader(sig), nil)
+		return gpgError(err)
+	case ".gpg":
+		_, err := openpgp.CheckDetachedSignature(k.gpg, bytes.NewReader(data), bytes.NewReader(sig), nil)
+		return gpgError(err)
+	}
+
+	return fmt.Errorf("unsupported signature %s", sigName)
+}
+
+func gpgError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, pgperrors.ErrUnknownIssuer) {
+		return errors.New("gpg signature made with an untrusted key")
+	}
+
+	return fmt.Errorf("gpg signature does not match: %w", err)
+}
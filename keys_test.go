@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"golang.org/x/crypto/blake2b"
+)
+
+type testMinisignKey struct {
+	id   [8]byte
+	priv ed25519.PrivateKey
+}
+
+func newTestMinisignKey(t *testing.T) testMinisignKey {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	k := testMinisignKey{priv: priv}
+	rand.Read(k.id[:])
+	return k
+}
+
+// public returns the key as minisign prints it in minisign.pub.
+func (k testMinisignKey) public() string {
+	b := append([]byte("Ed"), k.id[:]...)
+	return base64.StdEncoding.EncodeToString(append(b, k.priv.Public().(ed25519.PublicKey)...))
+}
+
+// sign returns a prehashed .minisig file for data, as minisign -S writes.
+func (k testMinisignKey) sign(data []byte, comment string) []byte {
+	hash := blake2b.Sum512(data)
+	sig := ed25519.Sign(k.priv, hash[:])
+	global := ed25519.Sign(k.priv, append(bytes.Clone(sig), comment...))
+
+	return []byte("untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte("ED"), k.id[:]...), sig...)) + "\n" +
+		"trusted comment: " + comment + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n")
+}
+
+func setTestTrustedKeys(t *testing.T, keys map[string]*repoKeys) {
+	t.Helper()
+
+	old := trustedKeys
+	trustedKeys = keys
+	t.Cleanup(func() { trustedKeys = old })
+}
+
+func TestVerifyMinisign(t *testing.T) {
+	key := newTestMinisignKey(t)
+	pub, err := parseMinisignKey(key.public())
+	if err != nil {
+		t.Fatalf("parseMinisignKey: %v", err)
+	}
+
+	data := []byte("checksums")
+	sig := key.sign(data, "timestamp:1700000000\tfile:SHA256SUMS")
+	if err := verifyMinisign([]minisignKey{pub}, data, sig); err != nil {
+		t.Fatalf("verifyMinisign: %v", err)
+	}
+
+	other, err := parseMinisignKey(newTestMinisignKey(t).public())
+	if err != nil {
+		t.Fatalf("parseMinisignKey: %v", err)
+	}
+
+	forged := bytes.Replace(sig, []byte("file:SHA256SUMS"), []byte("file:OTHERSUMS"), 1)
+	tests := []struct {
+		name    string
+		keys    []minisignKey
+		data    []byte
+		sig     []byte
+		wantErr string
+	}{
+		{"tampered data", []minisignKey{pub}, []byte("tampered"), sig, "signature does not match"},
+		{"untrusted key", []minisignKey{other}, data, sig, "untrusted key"},
+		{"forged trusted comment", []minisignKey{pub}, data, forged, "trusted comment signature does not match"},
+		{"garbage", []minisignKey{pub}, data, []byte("not a signature"), "invalid minisign signature"},
+	}
+
+	for _, tc := range tests {
+		err := verifyMinisign(tc.keys, tc.data, tc.sig)
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("%s: verifyMinisign error = %v, want %q", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestWithSignedChecksumMinisign(t *testing.T) {
+	key := newTestMinisignKey(t)
+	pub, err := parseMinisignKey(key.public())
+	if err != nil {
+		t.Fatalf("parseMinisignKey: %v", err)
+	}
+
+	setTestTrustedKeys(t, map[string]*repoKeys{"owner/tool": {minisign: []minisignKey{pub}}})
+
+	sum := sha256.Sum256([]byte("release asset"))
+	sums := []byte(hex.EncodeToString(sum[:]) + "  tool.tar.gz\n")
+	want := "sha256:" + hex.EncodeToString(sum[:])
+
+	release := Release{repo: "Owner/Tool", Assets: serveReleaseFiles(t, map[string][]byte{
+		"SHA256SUMS":         sums,
+		"SHA256SUMS.minisig": key.sign(sums, "SHA256SUMS"),
+	})}
+
+	got, err := withSignedChecksum(Asset{Name: "tool.tar.gz"}, release.repo, newReleaseChecksums(release.Assets))
+	if err != nil {
+		t.Fatalf("withSignedChecksum: %v", err)
+	}
+
+	if got.Digest != want {
+		t.Fatalf("withSignedChecksum digest = %q, want %q", got.Digest, want)
+	}
+
+	// A compromised asset with a matching GitHub digest is not enough.
+	if _, err := withSignedChecksum(Asset{Name: "tool.tar.gz", Digest: "sha256:" + strings.Repeat("0", 64)}, release.repo, newReleaseChecksums(release.Assets)); err == nil {
+		t.Fatal("withSignedChecksum accepted a digest that contradicts the signed checksum")
+	}
+
+	tampered := Release{repo: "owner/tool", Assets: serveReleaseFiles(t, map[string][]byte{
+		"SHA256SUMS":         []byte(strings.Repeat("0", 64) + "  tool.tar.gz\n"),
+		"SHA256SUMS.minisig": key.sign(sums, "SHA256SUMS"),
+	})}
+	if _, err := withSignedChecksum(Asset{Name: "tool.tar.gz"}, tampered.repo, newReleaseChecksums(tampered.Assets)); err == nil || !strings.Contains(err.Error(), "SHA256SUMS.minisig") {
+		t.Fatalf("withSignedChecksum error = %v, want SHA256SUMS.minisig failure", err)
+	}
+
+	unsigned := Release{repo: "owner/tool", Assets: serveReleaseFiles(t, map[string][]byte{"SHA256SUMS": sums})}
+	if _, err := withSignedChecksum(Asset{Name: "tool.tar.gz"}, unsigned.repo, newReleaseChecksums(unsigned.Assets)); err == nil || !strings.Contains(err.Error(), "no checksum file signed") {
+		t.Fatalf("withSignedChecksum error = %v, want no signed checksum file", err)
+	}
+
+	other := Release{repo: "other/tool", Assets: unsigned.Assets}
+	if got, err := withSignedChecksum(Asset{Name: "tool.tar.gz", Digest: want}, other.repo, newReleaseChecksums(other.Assets)); err != nil || got.Digest != want {
+		t.Fatalf("withSignedChecksum for a repo without keys = %q, %v; want it unchanged", got.Digest, err)
+	}
+}
+
+func TestWithSignedChecksumGPG(t *testing.T) {
+	configs := map[string]*packet.Config{
+		"rsa":     nil,
+		"ed25519": {Algorithm: packet.PubKeyAlgoEdDSA},
+	}
+
+	for name, config := range configs {
+		t.Run(name, func(t *testing.T) {
+			testWithSignedChecksumGPG(t, config)
+		})
+	}
+}
+
+func testWithSignedChecksumGPG(t *testing.T, config *packet.Config) {
+	entity, err := openpgp.NewEntity("Maintainer", "", "maintainer@example.com", config)
+	if err != nil {
+		t.Fatalf("NewEntity: %v", err)
+	}
+
+	var pub bytes.Buffer
+	w, err := armor.Encode(&pub, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatalf("armor.Encode: %v", err)
+	}
+
+	if err := entity.Serialize(w); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+
+	w.Close()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "maintainer.asc"), pub.Bytes(), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	keysPath := filepath.Join(dir, "keys.toml")
+	if err := os.WriteFile(keysPath, []byte("[[repo]]\nname = \"owner/tool\"\ngpg = [\"maintainer.asc\"]\n"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	keys, err := loadTrustedKeys(keysPath, true)
+	if err != nil {
+		t.Fatalf("loadTrustedKeys: %v", err)
+	}
+
+	setTestTrustedKeys(t, keys)
+
+	sum := sha256.Sum256([]byte("release asset"))
+	sums := []byte(hex.EncodeToString(sum[:]) + "  tool.tar.gz\n")
+	var sig bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&sig, entity, bytes.NewReader(sums), config); err != nil {
+		t.Fatalf("ArmoredDetachSign: %v", err)
+	}
+
+	release := Release{repo: "owner/tool", Assets: serveReleaseFiles(t, map[string][]byte{
+		"SHA256SUMS":     sums,
+		"SHA256SUMS.asc": sig.Bytes(),
+	})}
+
+	got, err := withSignedChecksum(Asset{Name: "tool.tar.gz"}, release.repo, newReleaseChecksums(release.Assets))
+	if err != nil {
+		t.Fatalf("withSignedChecksum: %v", err)
+	}
+
+	if want := "sha256:" + hex.EncodeToString(sum[:]); got.Digest != want {
+		t.Fatalf("withSignedChecksum digest = %q, want %q", got.Digest, want)
+	}
+
+	tampered := Release{repo: "owner/tool", Assets: serveReleaseFiles(t, map[string][]byte{
+		"SHA256SUMS":     append(bytes.Clone(sums), "0000  other\n"...),
+		"SHA256SUMS.asc": sig.Bytes(),
+	})}
+	if _, err := withSignedChecksum(Asset{Name: "tool.tar.gz"}, tampered.repo, newReleaseChecksums(tampered.Assets)); err == nil || !strings.Contains(err.Error(), "gpg signature") {
+		t.Fatalf("withSignedChecksum error = %v, want gpg signature failure", err)
+	}
+}
+
+func TestLoadTrustedKeys(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing.toml")
+	if keys, err := loadTrustedKeys(missing, false); err != nil || keys != nil {
+		t.Fatalf("loadTrustedKeys(missing, false) = %v, %v; want nil, nil", keys, err)
+	}
+
+	if _, err := loadTrustedKeys(missing, true); err == nil {
+		t.Fatal("loadTrustedKeys(missing, true) expected error")
+	}
+
+	pub := newTestMinisignKey(t).public()
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"valid", "[[repo]]\nname = \"owner/tool\"\nminisign = [\"" + pub + "\"]\n", ""},
+		{"no keys", "[[repo]]\nname = \"owner/tool\"\n", "no minisign or gpg key"},
+		{"bad key", "[[repo]]\nname = \"owner/tool\"\nminisign = [\"RWQ\"]\n", "invalid minisign public key"},
+		{"version", "[[repo]]\nname = \"owner/tool@v1\"\nminisign = [\"" + pub + "\"]\n", "unexpected version"},
+		{"unknown key", "[[repo]]\nname = \"owner/tool\"\nminisig = [\"" + pub + "\"]\n", "unknown keys"},
+		{"duplicate", strings.Repeat("[[repo]]\nname = \"owner/tool\"\nminisign = [\""+pub+"\"]\n", 2), "more than once"},
+	}
+
+	for _, tc := range tests {
+		path := filepath.Join(dir, "keys.toml")
+		if err := os.WriteFile(path, []byte(tc.content), 0644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+
+		keys, err := loadTrustedKeys(path, true)
+		if tc.wantErr == "" {
+			if err != nil || keys["owner/tool"] == nil {
+				t.Errorf("%s: loadTrustedKeys = %v, %v; want keys for owner/tool", tc.name, keys, err)
+			}
+
+			continue
+		}
+
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("%s: loadTrustedKeys error = %v, want %q", tc.name, err, tc.wantErr)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+const lockfileHeader = "# Generated by ghinst from the manifest next to it. Do not edit.\n\n"
+
+// lockfile records what each manifest entry resolved to, per platform, so a
+// later -locked install fetches exactly the same bytes.
+type lockfile struct {
+	Tools []lockEntry `toml:"tool"`
+}
+
+type lockEntry struct {
+	Repo     string `toml:"repo"`
+	Platform string `toml:"platform"`
+	Tag      string `toml:"tag"`
+	Asset    string `toml:"asset"`
+	URL      string `toml:"url"`
+	Size     int64  `toml:"size"`
+	Digest   string `toml:"digest"`
+}
+
+func currentPlatform() string {
+	return runtime.GOOS + "/" + runtime.GOARCH
+}
+
+// lockfilePath returns the lockfile that belongs to a manifest: ghinst.toml
+// is locked by ghinst.lock in the same directory.
+func lockfilePath(manifestPath string) string {
+	return strings.TrimSuffix(manifestPath, filepath.Ext(manifestPath)) + ".lock"
+}
+
+func newLockEntry(owner, repo, tag string, asset Asset) lockEntry {
+	return lockEntry{
+		Repo:     owner + "/" + repo,
+		Platform: currentPlatform(),
+		Tag:      tag,
+		Asset:    asset.Name,
+		URL:      asset.BrowserDownloadURL,
+		Size:     asset.Size,
+		Digest:   asset.Digest,
+	}
+}
+
+func loadLockfile(path string) (lockfile, error) {
+	var l lockfile
+	if _, err := toml.DecodeFile(path, &l); err != nil {
+		if os.IsNotExist(err) {
+			return lockfile{}, nil
+		}
+
+		return lockfile{}, fmt.Errorf("reading lockfile: %w", err)
+	}
+
+	return l, nil
+}
+
+func (l lockfile) find(repo, platform string) (lockEntry, bool) {
+	for _, e := range l.Tools {
+		if e.Repo == repo && e.Platform == platform {
+			return e, true
+		}
+	}
+
+	return lockEntry{}, false
+}
+
+// update replaces the entries for platform with entries and drops entries of
+// any platform for repos that are no longer in the manifest.
+func (l *lockfile) update(m manifest, platform string, entries []lockEntry) {
+	inManifest := map[string]bool{}
+	for _, t := range m.Tools {
+		owner, repo, _, _ := parseTarget(t.Repo)
+		inManifest[owner+"/"+repo] = true
+	}
+
+	tools := entries
+	for _, e := range l.Tools {
+		if e.Platform != platform && inManifest[e.Repo] {
+			tools = append(tools, e)
+		}
+	}
+
+	sort.Slice(tools, func(i, j int) bool {
+		if tools[i].Repo != tools[j].Repo {
+			return tools[i].Repo < tools[j].Repo
+		}
+
+		return tools[i].Platform < tools[j].Platform
+	})
+
+	l.Tools = tools
+}
+
+func writeLockfile(path string, l lockfile) error {
+	var buf bytes.Buffer
+	buf.WriteString(lockfileHeader)
+	enc := toml.NewEncoder(&buf)
+	enc.Indent = ""
+	if err := enc.Encode(l); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	tmpName := tmp.Name()
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	return nil
+}
+
+// lockedAsset returns the asset a manifest entry resolves to in release with
+// its digest filled in, downloading it when GitHub does not publish one.
+func lockedAsset(release Release, spec installSpec) (Asset, error) {
+	asset, err := releaseAsset(release, spec)
+	if err != nil {
+		return Asset{}, err
+	}
+
+	if asset.Digest != "" {
+		return asset, nil
+	}
+
+	tmp, err := downloadAndVerify(asset, int64(options.maxSize))
+	if err != nil {
+		return Asset{}, err
+	}
+
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if asset.Digest, err = fileDigest(tmp); err != nil {
+		return Asset{}, fmt.Errorf("computing checksum: %w", err)
+	}
+
+	return asset, nil
+}
+
+// installLocked installs every manifest tool exactly as recorded in the
+// lockfile for the current platform.
+func installLocked(m manifest, l lockfile) error {
+	failed := 0
+	for _, t := range m.Tools {
+		if err := installLockedTool(t, l); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s: %v\n", t.Repo, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d locked installs failed", failed, len(m.Tools))
+	}
+
+	return nil
+}
+
+func installLockedTool(t manifestTool, l lockfile) error {
+	owner, repo, tag, err := parseTarget(t.Repo)
+	if err != nil {
+		return err
+	}
+
+	entry, ok := l.find(owner+"/"+repo, currentPlatform())
+	if !ok {
+		return fmt.Errorf("lockfile has no entry for %s/%s on %s", owner, repo, currentPlatform())
+	}
+
+	if entry.Digest == "" {
+		return fmt.Errorf("lockfile entry for %s/%s has no digest", owner, repo)
+	}
+
+	if tag != "" && tag != entry.Tag {
+		return fmt.Errorf("lockfile is out of date: manifest wants %s, lockfile has %s", tag, entry.Tag)
+	}
+
+	installNeeded, err := ensureInstallNeeded(owner, repo, entry.Tag)
+	if err != nil {
+		return err
+	}
+
+	if !installNeeded {
+		return nil
+	}
+
+	release, err := fetchRelease(owner, repo, entry.Tag)
+	if err != nil {
+		return err
+	}
+
+	var upstream *Asset
+	for i := range release.Assets {
+		if release.Assets[i].Name == entry.Asset {
+			upstream = &release.Assets[i]
+			break
+		}
+	}
+
+	if upstream == nil {
+		return fmt.Errorf("asset %s is no longer published in %s", entry.Asset, entry.Tag)
+	}
+
+	if digestsDisagree(upstream.Digest, entry.Digest) {
+		return fmt.Errorf("upstream digest for %s no longer matches lockfile", entry.Asset)
+	}
+
+	asset := Asset{
+		Name:               entry.Asset,
+		BrowserDownloadURL: entry.URL,
+		Digest:             entry.Digest,
+		Size:               entry.Size,
+	}
+
+	sums := newReleaseChecksums(release.Assets)
+	if asset, err = withSignedChecksum(asset, release.repo, sums); err != nil {
+		return err
+	}
+
+	if asset, err = withSignatures(asset, sums); err != nil {
+		return err
+	}
+
+	// A signed checksum of another algorithm would replace the locked
+	// digest instead of contradicting it. The lockfile pins the bytes.
+	if !strings.EqualFold(asset.Digest, entry.Digest) {
+		return fmt.Errorf("signed checksum of %s is %s, but the lockfile pins %s; update the lockfile", entry.Asset, asset.Digest, entry.Digest)
+	}
+
+	asset = withAttestation(asset, release)
+
+	linkPaths, _, err := installReleaseAsset(owner, repo, entry.Tag, asset, t.spec())
+	if err != nil {
+		return err
+	}
+
+	printInstalled(repo, entry.Tag, linkPaths)
+	return nil
+}
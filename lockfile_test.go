@@ -0,0 +1,325 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLockfilePath(t *testing.T) {
+	if got := lockfilePath(filepath.Join("dir", "ghinst.toml")); got != filepath.Join("dir", "ghinst.lock") {
+		t.Fatalf("lockfilePath = %q, want %q", got, filepath.Join("dir", "ghinst.lock"))
+	}
+}
+
+func TestInstallManifestWritesLockfileAndLockedReinstalls(t *testing.T) {
+	tmpDir := t.TempDir()
+	setTestOptions(t, tmpDir)
+	latest := map[string]string{"owner/tool": "v2.0.0"}
+	srv := newTestReleaseServer(t, latest)
+
+	path := writeTestManifest(t, "[[tool]]\nrepo = \"owner/tool\"\n")
+	captureStderr(t, func() {
+		captureStdout(t, func() {
+			if err := installManifest(path); err != nil {
+				t.Fatalf("installManifest: %v", err)
+			}
+		})
+	})
+
+	lock, err := loadLockfile(lockfilePath(path))
+	if err != nil {
+		t.Fatalf("loadLockfile: %v", err)
+	}
+
+	if len(lock.Tools) != 1 {
+		t.Fatalf("lockfile tools = %v, want 1 entry", lock.Tools)
+	}
+
+	entry := lock.Tools[0]
+	if entry.Repo != "owner/tool" || entry.Platform != currentPlatform() || entry.Tag != "v2.0.0" || entry.Asset != testAssetName() {
+		t.Fatalf("lockfile entry = %+v", entry)
+	}
+
+	if entry.URL != srv.URL+"/dl/owner/tool/v2.0.0" {
+		t.Fatalf("lockfile url = %q", entry.URL)
+	}
+
+	if !strings.HasPrefix(entry.Digest, "sha256:") {
+		t.Fatalf("lockfile digest = %q, want computed sha256", entry.Digest)
+	}
+
+	// A newer upstream release must not change what a locked install gets.
+	latest["owner/tool"] = "v3.0.0"
+	options.baseDir = t.TempDir()
+	options.locked = true
+
+	out := captureStdout(t, func() {
+		if err := installManifest(path); err != nil {
+			t.Fatalf("installManifest -locked: %v", err)
+		}
+	})
+
+	if !strings.Contains(out, "installed tool (v2.0.0)") {
+		t.Fatalf("locked install output = %q, want v2.0.0 install", out)
+	}
+}
+
+func TestInstallLockedRejectsContentMismatch(t *testing.T) {
+	tmpDir := t.TempDir()
+	setTestOptions(t, tmpDir)
+	options.locked = true
+	srv := newTestReleaseServer(t, map[string]string{"owner/tool": "v1.0.0"})
+
+	path := writeTestManifest(t, "[[tool]]\nrepo = \"owner/tool\"\n")
+	lock := lockfile{Tools: []lockEntry{{
+		Repo:     "owner/tool",
+		Platform: currentPlatform(),
+		Tag:      "v1.0.0",
+		Asset:    testAssetName(),
+		URL:      srv.URL + "/dl/owner/tool/v1.0.0",
+		Digest:   "sha256:" + strings.Repeat("00", 32),
+	}}}
+	if err := writeLockfile(lockfilePath(path), lock); err != nil {
+		t.Fatalf("writeLockfile: %v", err)
+	}
+
+	var err error
+	stderr := captureStderr(t, func() {
+		captureStdout(t, func() {
+			err = installManifest(path)
+		})
+	})
+
+	if err == nil {
+		t.Fatal("installManifest -locked expected error for digest mismatch")
+	}
+
+	if !strings.Contains(stderr, "checksum mismatch") {
+		t.Fatalf("stderr = %q, want checksum mismatch", stderr)
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, "ghinst", "owner", "tool@"+encodeTagForPath("v1.0.0"))); !os.IsNotExist(err) {
+		t.Fatalf("mismatched asset must not be installed, stat err=%v", err)
+	}
+}
+
+func TestInstallLockedRejectsUpstreamDigestChange(t *testing.T) {
+	tmpDir := t.TempDir()
+	setTestOptions(t, tmpDir)
+	options.locked = true
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/tool/releases/tags/v1.0.0" {
+			t.Errorf("unexpected request %s", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		json.NewEncoder(w).Encode(Release{
+			TagName: "v1.0.0",
+			Assets: []Asset{{
+				Name:   testAssetName(),
+				Digest: "sha256:" + strings.Repeat("11", 32),
+			}},
+		})
+	}))
+	defer srv.Close()
+
+	old := apiBase
+	apiBase = srv.URL
+	defer func() { apiBase = old }()
+
+	path := writeTestManifest(t, "[[tool]]\nrepo = \"owner/tool@v1.0.0\"\n")
+	lock := lockfile{Tools: []lockEntry{{
+		Repo:     "owner/tool",
+		Platform: currentPlatform(),
+		Tag:      "v1.0.0",
+		Asset:    testAssetName(),
+		URL:      srv.URL + "/dl",
+		Digest:   "sha256:" + strings.Repeat("00", 32),
+	}}}
+	if err := writeLockfile(lockfilePath(path), lock); err != nil {
+		t.Fatalf("writeLockfile: %v", err)
+	}
+
+	var err error
+	stderr := captureStderr(t, func() {
+		err = installManifest(path)
+	})
+
+	if err == nil {
+		t.Fatal("installManifest -locked expected error for upstream digest change")
+	}
+
+	if !strings.Contains(stderr, "no longer matches lockfile") {
+		t.Fatalf("stderr = %q, want upstream digest error", stderr)
+	}
+}
+
+func TestInstallLockedIgnoresUpstreamDigestOfOtherAlgorithm(t *testing.T) {
+	tmpDir := t.TempDir()
+	setTestOptions(t, tmpDir)
+	options.locked = true
+
+	data, err := buildTarGz([]struct {
+		name string
+		mode int64
+		body []byte
+	}{{"tool", 0755, []byte("#!/bin/sh\n")}})
+	if err != nil {
+		t.Fatalf("buildTarGz: %v", err)
+	}
+
+	sum := sha512.Sum512(data)
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/dl" {
+			w.Write(data)
+			return
+		}
+
+		// GitHub reports sha256 while the lockfile holds the signed sha512.
+		json.NewEncoder(w).Encode(Release{
+			TagName: "v1.0.0",
+			Assets: []Asset{{
+				Name:               testAssetName(),
+				BrowserDownloadURL: srv.URL + "/dl",
+				Digest:             "sha256:" + strings.Repeat("11", 32),
+			}},
+		})
+	}))
+	defer srv.Close()
+
+	old := apiBase
+	apiBase = srv.URL
+	defer func() { apiBase = old }()
+
+	path := writeTestManifest(t, "[[tool]]\nrepo = \"owner/tool@v1.0.0\"\n")
+	lock := lockfile{Tools: []lockEntry{{
+		Repo:     "owner/tool",
+		Platform: currentPlatform(),
+		Tag:      "v1.0.0",
+		Asset:    testAssetName(),
+		URL:      srv.URL + "/dl",
+		Digest:   "sha512:" + hex.EncodeToString(sum[:]),
+	}}}
+	if err := writeLockfile(lockfilePath(path), lock); err != nil {
+		t.Fatalf("writeLockfile: %v", err)
+	}
+
+	captureStdout(t, func() {
+		if err := installManifest(path); err != nil {
+			t.Fatalf("installManifest -locked: %v", err)
+		}
+	})
+}
+
+func TestInstallLockedKeepsLockedDigestAuthoritative(t *testing.T) {
+	tmpDir := t.TempDir()
+	setTestOptions(t, tmpDir)
+	options.locked = true
+
+	build := func(body string) []byte {
+		data, err := buildTarGz([]struct {
+			name string
+			mode int64
+			body []byte
+		}{{"tool", 0755, []byte(body)}})
+		if err != nil {
+			t.Fatalf("buildTarGz: %v", err)
+		}
+
+		return data
+	}
+
+	locked, served := build("#!/bin/sh\necho locked\n"), build("#!/bin/sh\necho other\n")
+	lockedSum := sha512.Sum512(locked)
+	servedSum := sha256.Sum256(served)
+
+	// The checksum file is correctly signed, but lists other bytes under
+	// another algorithm than the lockfile.
+	key := newTestMinisignKey(t)
+	pub, err := parseMinisignKey(key.public())
+	if err != nil {
+		t.Fatalf("parseMinisignKey: %v", err)
+	}
+
+	setTestTrustedKeys(t, map[string]*repoKeys{"owner/tool": {minisign: []minisignKey{pub}}})
+	sums := []byte(hex.EncodeToString(servedSum[:]) + "  " + testAssetName() + "\n")
+	files := map[string][]byte{
+		testAssetName():      served,
+		"SHA256SUMS":         sums,
+		"SHA256SUMS.minisig": key.sign(sums, "SHA256SUMS"),
+	}
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if data, ok := files[strings.TrimPrefix(r.URL.Path, "/dl/")]; ok {
+			w.Write(data)
+			return
+		}
+
+		release := Release{TagName: "v1.0.0"}
+		for name, data := range files {
+			release.Assets = append(release.Assets, Asset{Name: name, BrowserDownloadURL: srv.URL + "/dl/" + name, Size: int64(len(data))})
+		}
+
+		json.NewEncoder(w).Encode(release)
+	}))
+	defer srv.Close()
+
+	old := apiBase
+	apiBase = srv.URL
+	defer func() { apiBase = old }()
+
+	path := writeTestManifest(t, "[[tool]]\nrepo = \"owner/tool@v1.0.0\"\n")
+	lock := lockfile{Tools: []lockEntry{{
+		Repo:     "owner/tool",
+		Platform: currentPlatform(),
+		Tag:      "v1.0.0",
+		Asset:    testAssetName(),
+		URL:      srv.URL + "/dl/" + testAssetName(),
+		Digest:   "sha512:" + hex.EncodeToString(lockedSum[:]),
+	}}}
+	if err := writeLockfile(lockfilePath(path), lock); err != nil {
+		t.Fatalf("writeLockfile: %v", err)
+	}
+
+	stderr := captureStderr(t, func() {
+		captureStdout(t, func() {
+			err = installManifest(path)
+		})
+	})
+
+	if err == nil || !strings.Contains(stderr, "the lockfile pins") {
+		t.Fatalf("installManifest -locked error = %v, stderr = %q; want locked digest enforced", err, stderr)
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, "ghinst", "owner", "tool@"+encodeTagForPath("v1.0.0"))); !os.IsNotExist(err) {
+		t.Fatalf("unpinned asset must not be installed, stat err=%v", err)
+	}
+}
+
+func TestInstallLockedRequiresEntry(t *testing.T) {
+	setTestOptions(t, t.TempDir())
+	options.locked = true
+
+	path := writeTestManifest(t, "[[tool]]\nrepo = \"owner/tool\"\n")
+
+	var err error
+	stderr := captureStderr(t, func() {
+		err = installManifest(path)
+	})
+
+	if err == nil || !strings.Contains(stderr, "lockfile has no entry for owner/tool") {
+		t.Fatalf("installManifest -locked err=%v stderr=%q, want missing entry error", err, stderr)
+	}
+}
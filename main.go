@@ -1,6 +1,8 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"io"
@@ -10,14 +12,41 @@ import (
 	"runtime/debug"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 var options struct {
 	showVersion bool
 	purge       bool
+	uninstall   bool
+	use         bool
 	list        bool
 	force       bool
+	all         bool
+	bins        stringList
+	linkName    string
+	asset       string
+	explain     bool
+	goos        string
+	goarch      string
+	output      string
+	download    bool
+	fromFile    string
+	sha256      string
+	cacheDir    string
+	cacheMax    byteSize
+	cacheClean  bool
+	quiet       bool
+	jobs        int
+	requireSum  bool
+	cosignKey   string
+	keys        string
+	attestation bool
+	upgrade     bool
+	outdated    bool
+	manifest    string
+	locked      bool
 	baseDir     string
 	completion  string
 	maxSize     byteSize
@@ -25,6 +54,7 @@ var options struct {
 }
 
 const (
+	defaultJobs                  = 4
 	defaultMaxAssetSizeMiB int64 = 200
 	mib                    int64 = 1 << 20
 )
@@ -49,14 +79,41 @@ func registerFlags(fs *flag.FlagSet) {
 	fs.StringVar(&options.completion, "completion", "", "print shell completion script (bash, zsh, fish)")
 	fs.BoolVar(&options.showVersion, "version", false, "print version and exit")
 	fs.BoolVar(&options.purge, "purge", false, "remove all but the currently used version of owner/repo")
+	fs.BoolVar(&options.uninstall, "uninstall", false, "remove every version of owner/repo and its symlinks")
+	fs.BoolVar(&options.use, "use", false, "switch owner/repo@version to an already installed version")
 	fs.BoolVar(&options.list, "list", false, "list installed apps")
 	fs.BoolVar(&options.force, "force", false, "install even if already on the latest version")
+	fs.StringVar(&options.asset, "asset", "", "glob or /regexp/ selecting the release asset to install")
+	fs.BoolVar(&options.explain, "explain", false, "show how release assets of owner/repo[@version] rank for this platform, without installing")
+	fs.StringVar(&options.goos, "os", "", "select assets for this GOOS instead of the running one (requires -output)")
+	fs.StringVar(&options.goarch, "arch", "", "select assets for this GOARCH instead of the running one (requires -output)")
+	fs.StringVar(&options.output, "output", "", "write the extracted binaries to this directory instead of installing them")
+	fs.BoolVar(&options.download, "download-only", false, "save the verified asset and a .sha256 file to -output (default: current directory) without installing")
+	fs.StringVar(&options.fromFile, "from-file", "", "install owner/repo@version from this local asset file instead of downloading it")
+	fs.StringVar(&options.sha256, "sha256", "", "with -from-file, the expected sha256 of the file in hex")
+	fs.Var(&options.bins, "bin", "install the archive entry with this base name or glob (repeatable)")
+	fs.StringVar(&options.linkName, "as", "", "name of the symlink created in the bin directory")
+	fs.BoolVar(&options.all, "all", false, "install every executable in the archive, not just the first")
+	fs.BoolVar(&options.upgrade, "upgrade", false, "upgrade every installed owner/repo to its latest release")
+	fs.StringVar(&options.manifest, "manifest", "", "install every tool listed in a ghinst.toml manifest")
+	fs.BoolVar(&options.locked, "locked", false, "with -manifest, install exactly what its lockfile records")
+	fs.BoolVar(&options.outdated, "outdated", false, "report installed owner/repo versions behind their latest release")
 	fs.StringVar(&options.baseDir, "dir", defaultBaseDir(), "base install directory (overrides GHINST_DIR)")
 	options.maxSize = byteSize(defaultMaxAssetSizeMiB * mib)
 	fs.Var(&options.maxSize, "max-size", "maximum asset or extracted binary size in bytes (supports kb, mb, gb suffixes)")
+	fs.StringVar(&options.cacheDir, "cache-dir", defaultCacheDir(), "download cache directory; empty disables the cache")
+	options.cacheMax = byteSize(defaultCacheMaxSizeMiB * mib)
+	fs.Var(&options.cacheMax, "cache-max-size", "evict the oldest cached downloads beyond this size (supports kb, mb, gb suffixes)")
+	fs.BoolVar(&options.cacheClean, "cache-clean", false, "remove every cached download")
+	fs.IntVar(&options.jobs, "jobs", defaultJobs, "install at most this many targets at once")
+	fs.BoolVar(&options.requireSum, "require-checksum", false, "fail instead of warn when an asset has no checksum to verify against")
+	fs.StringVar(&options.cosignKey, "cosign-key", "", "require cosign/Sigstore signatures verified against the PEM public keys in this file")
+	fs.StringVar(&options.keys, "keys", defaultKeysPath(), "file of per-repo minisign and gpg keys that checksum files must be signed with")
+	fs.BoolVar(&options.attestation, "verify-attestation", false, "require a GitHub attestation with SLSA provenance from owner/repo for the asset")
+	fs.BoolVar(&options.quiet, "quiet", false, "do not show download progress")
 	fs.DurationVar(&options.httpTimeout, "http-timeout", httpClient.Timeout, "HTTP timeout (supports time.ParseDuration formats)")
 	fs.Usage = func() {
-		fmt.Fprintf(os.Stderr, "usage: %s owner/repo[@version]\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "usage: %s owner/repo[@version]...\n", filepath.Base(os.Args[0]))
 		fs.PrintDefaults()
 	}
 }
@@ -84,6 +141,18 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Progress lines of concurrent downloads would overwrite each other.
+	showProgress = !options.quiet && flag.NArg() <= 1
+
+	if options.cacheClean {
+		if err := cleanCache(options.cacheDir); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	if options.list {
 		if err := listInstalled(options.baseDir); err != nil {
 			fmt.Fprintf(os.Stderr, "error: %v\n", err)
@@ -93,6 +162,68 @@ func main() {
 		return
 	}
 
+	if options.manifest != "" {
+		if flag.NArg() != 0 {
+			fmt.Fprintln(os.Stderr, "error: -manifest does not take arguments")
+			os.Exit(1)
+		}
+
+		if err := installManifest(options.manifest); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if options.outdated {
+		if flag.NArg() != 0 {
+			fmt.Fprintln(os.Stderr, "error: -outdated does not take arguments")
+			os.Exit(1)
+		}
+
+		if err := reportOutdated(options.baseDir); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if options.upgrade {
+		if flag.NArg() != 0 {
+			fmt.Fprintln(os.Stderr, "error: -upgrade does not take arguments")
+			os.Exit(1)
+		}
+
+		if err := upgradeInstalled(options.baseDir); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if flag.NArg() > 1 {
+		err := checkMultiTargetOptions()
+		if err == nil {
+			err = installTargets(flag.Args(), installSpec{
+				assetPattern: options.asset,
+				bins:         binarySelection{names: options.bins, all: options.all},
+				goos:         options.goos,
+				goarch:       options.goarch,
+				outputDir:    options.output,
+			}, options.jobs)
+		}
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	if flag.NArg() != 1 {
 		fmt.Fprintln(os.Stderr, "error: wrong number of arguments")
 		os.Exit(1)
@@ -106,10 +237,53 @@ func main() {
 		os.Exit(1)
 	}
 
-	if options.purge {
+	switch {
+	case options.uninstall:
+		if tag != "" {
+			err = fmt.Errorf("-uninstall removes every version; use owner/repo without @%s", tag)
+		} else {
+			err = uninstall(options.baseDir, owner, repo)
+		}
+	case options.use:
+		if tag == "" {
+			err = fmt.Errorf("-use requires owner/repo@version")
+		} else {
+			err = useVersion(options.baseDir, owner, repo, tag)
+		}
+	case options.purge:
 		err = purge(options.baseDir, owner, repo)
-	} else {
-		err = handleInstall(owner, repo, tag)
+	case options.fromFile != "":
+		if tag == "" {
+			err = fmt.Errorf("-from-file requires owner/repo@version")
+		} else {
+			err = installFromFile(owner, repo, tag, options.fromFile, options.sha256, installSpec{
+				bins:      binarySelection{names: options.bins, all: options.all},
+				linkName:  options.linkName,
+				outputDir: options.output,
+			})
+		}
+	case options.download:
+		err = downloadOnly(owner, repo, tag, installSpec{
+			assetPattern: options.asset,
+			goos:         options.goos,
+			goarch:       options.goarch,
+			outputDir:    options.output,
+		})
+	case options.explain:
+		err = explainSelection(os.Stdout, owner, repo, tag, installSpec{
+			assetPattern: options.asset,
+			goos:         options.goos,
+			goarch:       options.goarch,
+		})
+	default:
+		err = handleInstall(owner, repo, tag, installSpec{
+			assetPattern: options.asset,
+			bins:         binarySelection{names: options.bins, all: options.all},
+			linkName:     options.linkName,
+			goos:         options.goos,
+			goarch:       options.goarch,
+			outputDir:    options.output,
+		})
 	}
 
 	if err != nil {
@@ -131,38 +305,476 @@ func validateOptions() error {
 		return fmt.Errorf("-http-timeout must be greater than 0")
 	}
 
+	if options.jobs <= 0 {
+		return fmt.Errorf("-jobs must be greater than 0")
+	}
+
+	if options.cacheMax < 0 {
+		return fmt.Errorf("-cache-max-size must not be negative")
+	}
+
+	if options.asset != "" {
+		if _, err := assetMatcher(options.asset); err != nil {
+			return err
+		}
+	}
+
+	for _, bin := range options.bins {
+		if err := validateBinaryPattern(bin); err != nil {
+			return err
+		}
+	}
+
+	if options.linkName != "" {
+		if err := validatePathComponent("link name", options.linkName); err != nil {
+			return err
+		}
+
+		if options.all {
+			return fmt.Errorf("-as cannot be combined with -all")
+		}
+	}
+
+	if options.locked && options.manifest == "" {
+		return fmt.Errorf("-locked requires -manifest")
+	}
+
+	if options.goos != "" || options.goarch != "" {
+		goos, goarch := installSpec{goos: options.goos, goarch: options.goarch}.platform()
+		if err := checkPlatform(goos, goarch); err != nil {
+			return err
+		}
+
+		if options.output == "" && !options.explain && !options.download {
+			return fmt.Errorf("-os and -arch require -output")
+		}
+	}
+
+	if (options.output != "" || options.download) && (options.manifest != "" || options.upgrade) {
+		return fmt.Errorf("-output and -download-only cannot be combined with -manifest or -upgrade")
+	}
+
+	if options.sha256 != "" {
+		if options.fromFile == "" {
+			return fmt.Errorf("-sha256 requires -from-file")
+		}
+
+		if b, err := hex.DecodeString(options.sha256); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("invalid -sha256 %q: want %d hex digits", options.sha256, 2*sha256.Size)
+		}
+	}
+
+	if options.fromFile != "" && (options.download || options.manifest != "" || options.upgrade) {
+		return fmt.Errorf("-from-file cannot be combined with -download-only, -manifest or -upgrade")
+	}
+
+	if options.fromFile != "" && options.cosignKey != "" {
+		return fmt.Errorf("-cosign-key cannot be combined with -from-file")
+	}
+
+	if options.fromFile != "" && options.attestation {
+		return fmt.Errorf("-verify-attestation cannot be combined with -from-file")
+	}
+
 	httpClient.Timeout = options.httpTimeout
+	requireChecksum = options.requireSum
+	verifyAttestations = options.attestation
+
+	cosignTrust = nil
+	if options.cosignKey != "" {
+		trust, err := loadCosignTrust(options.cosignKey)
+		if err != nil {
+			return fmt.Errorf("invalid -cosign-key: %w", err)
+		}
+
+		cosignTrust = trust
+	}
+
+	trustedKeys = nil
+	if options.keys != "" {
+		keys, err := loadTrustedKeys(options.keys, options.keys != defaultKeysPath())
+		if err != nil {
+			return err
+		}
+
+		trustedKeys = keys
+	}
+
+	return nil
+}
+
+// checkMultiTargetOptions rejects options that only make sense for a single
+// owner/repo when several are given.
+func checkMultiTargetOptions() error {
+	for _, o := range []struct {
+		set  bool
+		name string
+	}{
+		{options.uninstall, "-uninstall"},
+		{options.use, "-use"},
+		{options.purge, "-purge"},
+		{options.explain, "-explain"},
+		{options.download, "-download-only"},
+		{options.fromFile != "", "-from-file"},
+		{options.linkName != "", "-as"},
+	} {
+		if o.set {
+			return fmt.Errorf("%s takes a single owner/repo", o.name)
+		}
+	}
 
 	return nil
 }
 
-func handleInstall(owner, repo, tag string) error {
+// installTargets installs several owner/repo[@version] targets concurrently,
+// at most jobs at a time. A failure on one target does not stop the others;
+// a summary lists the failed ones.
+func installTargets(targets []string, spec installSpec, jobs int) error {
+	type target struct {
+		owner, repo, tag string
+	}
+
+	parsed := make([]target, len(targets))
+	seen := make(map[string]bool, len(targets))
+	for i, s := range targets {
+		owner, repo, tag, err := parseTarget(s)
+		if err != nil {
+			return err
+		}
+
+		// Two versions of one repo would race on its directories and links.
+		slug := owner + "/" + repo
+		if seen[slug] {
+			return fmt.Errorf("%s is listed more than once", slug)
+		}
+
+		seen[slug] = true
+		parsed[i] = target{owner, repo, tag}
+	}
+
+	errs := make([]error, len(parsed))
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	for i, t := range parsed {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			errs[i] = handleInstall(t.owner, t.repo, t.tag, spec)
+			if errs[i] != nil {
+				fmt.Fprintf(os.Stderr, "error: %s: %v\n", targets[i], errs[i])
+			}
+		})
+	}
+
+	wg.Wait()
+
+	var failed []string
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, targets[i])
+		}
+	}
+
+	fmt.Printf("%d of %d targets succeeded\n", len(targets)-len(failed), len(targets))
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d installs failed: %s", len(failed), len(targets), strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// installSpec overrides how the asset and binary are picked from a release.
+type installSpec struct {
+	assetPattern string // glob or /regexp/ matched against asset names
+	bins         binarySelection
+	linkName     string // name of the symlink in bin instead of the binary's
+	goos, goarch string // target platform; empty for the running one
+	outputDir    string // write binaries here instead of the managed layout
+}
+
+// platform returns the GOOS and GOARCH assets are selected for.
+func (s installSpec) platform() (goos, goarch string) {
+	goos, goarch = runtime.GOOS, runtime.GOARCH
+	if s.goos != "" {
+		goos = s.goos
+	}
+
+	if s.goarch != "" {
+		goarch = s.goarch
+	}
+
+	return goos, goarch
+}
+
+func handleInstall(owner, repo, tag string, spec installSpec) error {
 	release, err := fetchRelease(owner, repo, tag)
 	if err != nil {
 		return err
 	}
 
-	installNeeded, err := ensureInstallNeeded(owner, repo, release.TagName)
+	if spec.outputDir == "" {
+		installNeeded, err := ensureInstallNeeded(owner, repo, release.TagName)
+		if err != nil {
+			return err
+		}
+
+		if !installNeeded {
+			return nil
+		}
+	}
+
+	linkPaths, _, err := installRelease(owner, repo, release, spec)
 	if err != nil {
 		return err
 	}
 
-	if !installNeeded {
-		return nil
+	printInstalled(repo, release.TagName, linkPaths)
+	return nil
+}
+
+func printInstalled(repo, tag string, linkPaths []string) {
+	for _, linkPath := range linkPaths {
+		fmt.Printf("installed %s (%s) → %s\n", repo, tag, linkPath)
 	}
+}
 
-	asset, err := selectAsset(release.Assets, runtime.GOOS, runtime.GOARCH)
+// installRelease installs the selected asset of release and returns the link
+// paths along with the asset, its Digest set to the one verified on download.
+func installRelease(owner, repo string, release Release, spec installSpec) ([]string, Asset, error) {
+	asset, err := releaseAsset(release, spec)
 	if err != nil {
 		printAvailableAssets(release.Assets)
+		return nil, Asset{}, err
+	}
+
+	return installReleaseAsset(owner, repo, release.TagName, asset, spec)
+}
+
+// releaseAsset selects the asset of release to install, with its digest
+// taken from a published checksum file when GitHub does not provide one, or
+// from a signed one when the repo has trusted keys, its signatures attached
+// when -cosign-key is set and marked for attestation checks when
+// -verify-attestation is set.
+func releaseAsset(release Release, spec installSpec) (Asset, error) {
+	asset, err := selectReleaseAsset(release.Assets, spec)
+	if err != nil {
+		return Asset{}, err
+	}
+
+	// The checks below share each checksum file, fetched once.
+	sums := newReleaseChecksums(release.Assets)
+	asset, err = withSignedChecksum(withPublishedDigest(asset, sums), release.repo, sums)
+	if err != nil {
+		return Asset{}, err
+	}
+
+	asset, err = withSignatures(asset, sums)
+	if err != nil {
+		return Asset{}, err
+	}
+
+	return withAttestation(asset, release), nil
+}
+
+func selectReleaseAsset(assets []Asset, spec installSpec) (Asset, error) {
+	goos, goarch := spec.platform()
+	if spec.assetPattern == "" {
+		return selectAsset(assets, goos, goarch)
+	}
+
+	matched, err := filterAssets(assets, spec.assetPattern)
+	if err != nil {
+		return Asset{}, err
+	}
+
+	switch len(matched) {
+	case 0:
+		return Asset{}, fmt.Errorf("no asset matches %q", spec.assetPattern)
+	case 1:
+		return matched[0], nil
+	}
+
+	asset, err := selectAsset(matched, goos, goarch)
+	if err != nil {
+		return Asset{}, fmt.Errorf("asset pattern %q matches %d assets; make it more specific", spec.assetPattern, len(matched))
+	}
+
+	return asset, nil
+}
+
+// downloadOnly saves the selected asset of a release, verified against its
+// digest, to spec.outputDir under its original name, next to a sha256sum-style
+// <asset>.sha256 file. Nothing is extracted or installed.
+func downloadOnly(owner, repo, tag string, spec installSpec) error {
+	release, err := fetchRelease(owner, repo, tag)
+	if err != nil {
 		return err
 	}
 
-	linkPath, err := installReleaseAsset(owner, repo, release.TagName, asset)
+	asset, err := releaseAsset(release, spec)
 	if err != nil {
+		printAvailableAssets(release.Assets)
+		return err
+	}
+
+	if err := validatePathComponent("asset name", asset.Name); err != nil {
+		return err
+	}
+
+	tmp, err := downloadAndVerify(asset, int64(options.maxSize))
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	digest, err := fileDigest(tmp)
+	if err != nil {
+		return fmt.Errorf("computing checksum: %w", err)
+	}
+
+	dir := spec.outputDir
+	if dir == "" {
+		dir = "."
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	assetPath := filepath.Join(dir, asset.Name)
+	if err := writeFileAtomic(dir, assetPath, tmp, 0644); err != nil {
+		return fmt.Errorf("writing asset: %w", err)
+	}
+
+	sum := strings.TrimPrefix(digest, "sha256:") + "  " + asset.Name + "\n"
+	if err := writeFileAtomic(dir, assetPath+".sha256", strings.NewReader(sum), 0644); err != nil {
+		return fmt.Errorf("writing checksum: %w", err)
+	}
+
+	fmt.Printf("downloaded %s/%s (%s) → %s\n", owner, repo, release.TagName, assetPath)
+	return nil
+}
+
+// explainAssetLimit is how many ranked assets -explain prints.
+const explainAssetLimit = 10
+
+// explainSelection prints the ranked asset candidates of a release for the
+// platform of spec, narrowed by its asset pattern when it is set.
+func explainSelection(w io.Writer, owner, repo, tag string, spec installSpec) error {
+	goos, goarch := spec.platform()
+	if err := checkPlatform(goos, goarch); err != nil {
+		return err
+	}
+
+	release, err := fetchRelease(owner, repo, tag)
+	if err != nil {
+		return err
+	}
+
+	assets := release.Assets
+	if spec.assetPattern != "" {
+		if assets, err = filterAssets(assets, spec.assetPattern); err != nil {
+			return err
+		}
+
+		if len(assets) == 0 {
+			return fmt.Errorf("no asset matches %q", spec.assetPattern)
+		}
+	}
+
+	libc := hostLibc(goos)
+	fmt.Fprintf(w, "%s/%s %s\n", owner, repo, release.TagName)
+	printAssetRanking(w, rankAssets(assets, goos, goarch, libc), goos, goarch, libc, explainAssetLimit)
+	return nil
+}
+
+// upgradeInstalled installs the latest release of every owner/repo whose
+// active version differs from it. A failure on one repo does not stop the
+// others; the combined error reports how many failed.
+func upgradeInstalled(baseDir string) error {
+	current, err := activeVersions(baseDir)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, v := range current {
+		if err := upgradeVersion(v); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s/%s: %v\n", v.Owner, v.Repo, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d upgrades failed", failed, len(current))
+	}
+
+	return nil
+}
+
+// reportOutdated prints the active and latest tag of every installed
+// owner/repo without installing anything. It returns an error when any repo
+// is behind or its latest release could not be fetched, so callers can gate
+// on the exit status.
+func reportOutdated(baseDir string) error {
+	current, err := activeVersions(baseDir)
+	if err != nil {
+		return err
+	}
+
+	outdated, failed := 0, 0
+	for _, v := range current {
+		release, err := fetchRelease(v.Owner, v.Repo, "")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s/%s: %v\n", v.Owner, v.Repo, err)
+			failed++
+			continue
+		}
+
+		status := "current"
+		if release.TagName != v.Tag {
+			status = "outdated"
+			outdated++
+		}
+
+		fmt.Printf("%s/%s %s %s %s\n", v.Owner, v.Repo, v.Tag, release.TagName, status)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("could not check %d of %d installed repos", failed, len(current))
+	}
+
+	if outdated > 0 {
+		return fmt.Errorf("%d of %d installed repos are outdated", outdated, len(current))
+	}
+
+	return nil
+}
+
+func upgradeVersion(v installedVersion) error {
+	release, err := fetchRelease(v.Owner, v.Repo, "")
+	if err != nil {
+		return err
+	}
+
+	if release.TagName == v.Tag && !options.force {
+		fmt.Printf("%s/%s is already at %s\n", v.Owner, v.Repo, v.Tag)
+		return nil
+	}
+
+	rec, _, err := readInstallRecord(v.Dir)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := installRelease(v.Owner, v.Repo, release, rec.spec()); err != nil {
 		return err
 	}
 
-	fmt.Printf("installed %s (%s) → %s\n", repo, release.TagName, linkPath)
+	fmt.Printf("upgraded %s/%s %s → %s\n", v.Owner, v.Repo, v.Tag, release.TagName)
 	return nil
 }
 
@@ -189,36 +801,132 @@ func ensureInstallNeeded(owner, repo, tag string) (bool, error) {
 	return true, nil
 }
 
-func installReleaseAsset(owner, repo, tag string, asset Asset) (string, error) {
+func installReleaseAsset(owner, repo, tag string, asset Asset, spec installSpec) ([]string, Asset, error) {
+	tmp, err := downloadAndVerify(asset, int64(options.maxSize))
+	if err != nil {
+		return nil, Asset{}, err
+	}
+
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	return installAssetFile(owner, repo, tag, asset, tmp, spec)
+}
+
+// installFromFile installs owner/repo@tag from a local copy of its release
+// asset into the managed layout, verifying it against sha256Hex when given.
+// Repos with trusted keys are refused: there is no signed checksum file to
+// check a local copy against.
+func installFromFile(owner, repo, tag, path, sha256Hex string, spec installSpec) error {
+	if trustedKeys[strings.ToLower(owner+"/"+repo)] != nil {
+		return fmt.Errorf("%s/%s has trusted keys in %s; -from-file cannot verify its signed checksums", owner, repo, options.keys)
+	}
+
+	if spec.outputDir == "" {
+		installNeeded, err := ensureInstallNeeded(owner, repo, tag)
+		if err != nil {
+			return err
+		}
+
+		if !installNeeded {
+			return nil
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
 	maxAssetSize := int64(options.maxSize)
-	tmp, err := downloadAndVerify(asset, maxAssetSize)
+	tmp, err := copyToTempFile("", "ghinst-*", f, maxAssetSize)
 	if err != nil {
-		return "", err
+		return fmt.Errorf("reading %s: %w", path, err)
 	}
 
 	defer os.Remove(tmp.Name())
 	defer tmp.Close()
 
-	binName, binFile, err := extractBinary(tmp, asset.Name, extractedBinarySizeLimit(maxAssetSize))
+	asset := Asset{Name: filepath.Base(path)}
+	if sha256Hex != "" {
+		asset.Digest = "sha256:" + strings.ToLower(sha256Hex)
+	}
+
+	if err := verifyAssetDigest(asset, tmp); err != nil {
+		return fmt.Errorf("verifying checksum: %w", err)
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	linkPaths, _, err := installAssetFile(owner, repo, tag, asset, tmp, spec)
+	if err != nil {
+		return err
+	}
+
+	printInstalled(repo, tag, linkPaths)
+	return nil
+}
+
+// installAssetFile extracts the binaries of the verified asset in tmp and
+// installs them, or writes them to spec.outputDir when it is set.
+func installAssetFile(owner, repo, tag string, asset Asset, tmp *os.File, spec installSpec) ([]string, Asset, error) {
+	if asset.Digest == "" {
+		digest, err := fileDigest(tmp)
+		if err != nil {
+			return nil, Asset{}, fmt.Errorf("computing checksum: %w", err)
+		}
+
+		asset.Digest = digest
+	}
+
+	bins, err := extractBinaries(tmp, asset.Name, spec.bins, extractedBinarySizeLimit(int64(options.maxSize)))
 	if err != nil {
-		return "", fmt.Errorf("extracting: %w", err)
+		return nil, Asset{}, fmt.Errorf("extracting: %w", err)
 	}
 
-	defer os.Remove(binFile.Name())
-	defer binFile.Close()
+	defer closeExtracted(bins)
+
+	if spec.outputDir != "" {
+		paths, err := writeBinaries(spec.outputDir, bins, spec.linkName)
+		if err != nil {
+			return nil, Asset{}, fmt.Errorf("writing: %w", err)
+		}
+
+		return paths, asset, nil
+	}
 
-	linkPath, err := installBinary(options.baseDir, owner, repo, tag, binName, binFile)
+	linkPaths, err := installBinaries(options.baseDir, owner, repo, tag, bins, spec)
 	if err != nil {
-		return "", fmt.Errorf("installing: %w", err)
+		return nil, Asset{}, fmt.Errorf("installing: %w", err)
 	}
 
-	return linkPath, nil
+	return linkPaths, asset, nil
 }
 
 func extractedBinarySizeLimit(maxAssetSize int64) int64 {
 	return maxAssetSize
 }
 
+// stringList is a flag that may be given more than once.
+type stringList []string
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func (l *stringList) String() string {
+	if l == nil {
+		return ""
+	}
+
+	return strings.Join(*l, ",")
+}
+
 type byteSize int64
 
 func (s *byteSize) Set(value string) error {
@@ -282,8 +990,40 @@ func parseByteSize(value string) (int64, error) {
 	return n, nil
 }
 
+// downloadAndVerify returns the verified asset in a temp file, from the
+// download cache when it holds the asset's digest, otherwise downloaded and
+// then added to the cache. Signatures and attestations required for the
+// asset are checked against its bytes either way.
 func downloadAndVerify(asset Asset, maxAssetSize int64) (*os.File, error) {
-	tmp, err := download(asset.BrowserDownloadURL, asset.Size, maxAssetSize)
+	tmp, err := fetchVerifiedAsset(asset, maxAssetSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifyAssetSignatures(asset, tmp); err != nil {
+		os.Remove(tmp.Name())
+		tmp.Close()
+		return nil, fmt.Errorf("verifying signature: %w", err)
+	}
+
+	if err := verifyAssetAttestation(asset, tmp); err != nil {
+		os.Remove(tmp.Name())
+		tmp.Close()
+		return nil, fmt.Errorf("verifying attestation: %w", err)
+	}
+
+	return tmp, nil
+}
+
+// fetchVerifiedAsset returns asset from the cache, or downloads it, checks
+// its digest and caches it. Assets without a strong digest are never
+// cached: a download verified weakly or not at all must not be reused.
+func fetchVerifiedAsset(asset Asset, maxAssetSize int64) (*os.File, error) {
+	if tmp := openCachedAsset(options.cacheDir, asset, maxAssetSize); tmp != nil {
+		return tmp, nil
+	}
+
+	tmp, err := download(asset.BrowserDownloadURL, asset.Size, maxAssetSize, true)
 	if err != nil {
 		return nil, fmt.Errorf("downloading: %w", err)
 	}
@@ -306,6 +1046,17 @@ func downloadAndVerify(asset Asset, maxAssetSize int64) (*os.File, error) {
 		return nil, fmt.Errorf("preparing downloaded asset: %w", err)
 	}
 
+	if options.cacheDir != "" && cacheable(asset.Digest) {
+		if err := storeCachedAsset(options.cacheDir, tmp, asset.Digest, int64(options.cacheMax)); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: caching %s: %v\n", asset.Name, err)
+			if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+				os.Remove(tmp.Name())
+				tmp.Close()
+				return nil, fmt.Errorf("preparing downloaded asset: %w", err)
+			}
+		}
+	}
+
 	return tmp, nil
 }
 
@@ -330,25 +1081,10 @@ func isHealthyInstallDir(installDir string) (bool, error) {
 		return false, fmt.Errorf("install path is not a directory: %s", installDir)
 	}
 
-	entries, err := os.ReadDir(installDir)
+	bins, err := installedBinaries(installDir)
 	if err != nil {
 		return false, err
 	}
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-
-		fi, err := entry.Info()
-		if err != nil {
-			continue
-		}
-
-		if fi.Mode().IsRegular() && fi.Mode()&0111 != 0 {
-			return true, nil
-		}
-	}
-
-	return false, nil
+	return len(bins) > 0, nil
 }
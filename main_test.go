@@ -1,9 +1,18 @@
 package main
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"testing"
@@ -142,6 +151,7 @@ func TestValidateOptionsUpdatesHTTPClientTimeout(t *testing.T) {
 
 	options.baseDir = t.TempDir()
 	options.maxSize = 1
+	options.jobs = 1
 	options.httpTimeout = 45 * time.Second
 
 	if err := validateOptions(); err != nil {
@@ -160,3 +170,628 @@ func TestExtractedBinarySizeLimitMatchesConfiguredMaxSize(t *testing.T) {
 		}
 	}
 }
+
+func testAssetName() string {
+	return fmt.Sprintf("tool_%s_%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+}
+
+// newTestReleaseServer fakes the GitHub API and download host. latest maps
+// "owner/repo" to the tag returned as its latest release; any tag of a listed
+// repo can also be fetched. Each release has a single tar.gz asset holding an
+// executable named after the repo.
+func newTestReleaseServer(t *testing.T, latest map[string]string) *httptest.Server {
+	t.Helper()
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/repos/"):
+			slug, release, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/repos/"), "/releases/")
+			tag, ok := latest[slug]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+
+			if pinned, ok := strings.CutPrefix(release, "tags/"); ok {
+				tag = pinned
+			}
+
+			json.NewEncoder(w).Encode(Release{
+				TagName: tag,
+				Assets: []Asset{{
+					Name:               testAssetName(),
+					BrowserDownloadURL: srv.URL + "/dl/" + slug + "/" + tag,
+				}},
+			})
+
+		case strings.HasPrefix(r.URL.Path, "/dl/"):
+			parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/dl/"), "/")
+			data, err := buildTarGz([]struct {
+				name string
+				mode int64
+				body []byte
+			}{{parts[1], 0755, []byte("#!/bin/sh\necho " + parts[2] + "\n")}})
+			if err != nil {
+				t.Errorf("buildTarGz: %v", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Write(data)
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	old := apiBase
+	apiBase = srv.URL
+	t.Cleanup(func() { apiBase = old })
+
+	return srv
+}
+
+func installTestVersion(t *testing.T, baseDir, owner, repo, tag string) {
+	t.Helper()
+
+	if _, err := installBinary(baseDir, owner, repo, tag, repo, bytes.NewReader([]byte("bin"))); err != nil {
+		t.Fatalf("installBinary %s/%s@%s: %v", owner, repo, tag, err)
+	}
+}
+
+func setTestOptions(t *testing.T, baseDir string) {
+	t.Helper()
+
+	old := options
+	t.Cleanup(func() { options = old })
+
+	options.baseDir = baseDir
+	options.maxSize = byteSize(1 << 20)
+	options.force = false
+	options.cacheDir = ""
+	options.keys = filepath.Join(baseDir, "keys.toml")
+}
+
+func TestUpgradeInstalled(t *testing.T) {
+	tmpDir := t.TempDir()
+	setTestOptions(t, tmpDir)
+	newTestReleaseServer(t, map[string]string{
+		"owner/old":     "v2.0.0",
+		"owner/current": "v1.0.0",
+	})
+
+	installTestVersion(t, tmpDir, "owner", "old", "v1.0.0")
+	installTestVersion(t, tmpDir, "owner", "current", "v1.0.0")
+
+	out := captureStdout(t, func() {
+		if err := upgradeInstalled(tmpDir); err != nil {
+			t.Fatalf("upgradeInstalled: %v", err)
+		}
+	})
+
+	want := "owner/current is already at v1.0.0\nupgraded owner/old v1.0.0 → v2.0.0\n"
+	if out != want {
+		t.Fatalf("upgradeInstalled output mismatch\n got:\n%q\nwant:\n%q", out, want)
+	}
+
+	target, err := os.Readlink(filepath.Join(tmpDir, "bin", "old"))
+	if err != nil {
+		t.Fatalf("Readlink: %v", err)
+	}
+
+	wantTarget := filepath.Join(tmpDir, "ghinst", "owner", "old@"+encodeTagForPath("v2.0.0"), "old")
+	if target != wantTarget {
+		t.Fatalf("symlink target = %q, want %q", target, wantTarget)
+	}
+}
+
+func TestUpgradeInstalledContinuesAfterFailure(t *testing.T) {
+	tmpDir := t.TempDir()
+	setTestOptions(t, tmpDir)
+	newTestReleaseServer(t, map[string]string{
+		"owner/ok": "v2.0.0",
+	})
+
+	installTestVersion(t, tmpDir, "owner", "gone", "v1.0.0")
+	installTestVersion(t, tmpDir, "owner", "ok", "v1.0.0")
+
+	var err error
+	captureStderr(t, func() {
+		captureStdout(t, func() {
+			err = upgradeInstalled(tmpDir)
+		})
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 upgrades failed") {
+		t.Fatalf("upgradeInstalled error = %v, want 1 of 2 failed", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, "ghinst", "owner", "ok@"+encodeTagForPath("v2.0.0"), "ok")); err != nil {
+		t.Fatalf("owner/ok should have been upgraded: %v", err)
+	}
+}
+
+func TestReportOutdated(t *testing.T) {
+	tmpDir := t.TempDir()
+	setTestOptions(t, tmpDir)
+	newTestReleaseServer(t, map[string]string{
+		"owner/old":     "v2.0.0",
+		"owner/current": "v1.0.0",
+	})
+
+	installTestVersion(t, tmpDir, "owner", "old", "v1.0.0")
+	installTestVersion(t, tmpDir, "owner", "current", "v1.0.0")
+
+	var err error
+	out := captureStdout(t, func() {
+		err = reportOutdated(tmpDir)
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 installed repos are outdated") {
+		t.Fatalf("reportOutdated error = %v, want 1 of 2 outdated", err)
+	}
+
+	want := "owner/current v1.0.0 v1.0.0 current\nowner/old v1.0.0 v2.0.0 outdated\n"
+	if out != want {
+		t.Fatalf("reportOutdated output mismatch\n got:\n%q\nwant:\n%q", out, want)
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, "ghinst", "owner", "old@"+encodeTagForPath("v2.0.0"))); !os.IsNotExist(err) {
+		t.Fatalf("reportOutdated must not install anything, stat err=%v", err)
+	}
+}
+
+func TestReportOutdatedAllCurrent(t *testing.T) {
+	tmpDir := t.TempDir()
+	setTestOptions(t, tmpDir)
+	newTestReleaseServer(t, map[string]string{"owner/current": "v1.0.0"})
+
+	installTestVersion(t, tmpDir, "owner", "current", "v1.0.0")
+
+	captureStdout(t, func() {
+		if err := reportOutdated(tmpDir); err != nil {
+			t.Fatalf("reportOutdated: %v", err)
+		}
+	})
+}
+
+func TestUpgradeInstalledKeepsBinarySelection(t *testing.T) {
+	tmpDir := t.TempDir()
+	setTestOptions(t, tmpDir)
+	newTestReleaseServer(t, map[string]string{"owner/tool": "v2.0.0"})
+
+	bin, err := writeTempFile(bytes.NewReader([]byte("bin")), 1<<20)
+	if err != nil {
+		t.Fatalf("writeTempFile: %v", err)
+	}
+
+	bins := []extractedBinary{{"tool", bin}}
+	defer closeExtracted(bins)
+
+	if _, err := installBinaries(tmpDir, "owner", "tool", "v1.0.0", bins, installSpec{bins: binarySelection{all: true}}); err != nil {
+		t.Fatalf("installBinaries: %v", err)
+	}
+
+	captureStderr(t, func() {
+		captureStdout(t, func() {
+			if err := upgradeInstalled(tmpDir); err != nil {
+				t.Fatalf("upgradeInstalled: %v", err)
+			}
+		})
+	})
+
+	rec, ok, err := readInstallRecord(filepath.Join(tmpDir, "ghinst", "owner", "tool@"+encodeTagForPath("v2.0.0")))
+	if err != nil || !ok {
+		t.Fatalf("readInstallRecord ok=%v err=%v", ok, err)
+	}
+
+	if !rec.All {
+		t.Fatalf("upgraded install record = %+v, want all", rec)
+	}
+}
+
+func TestHandleInstallAsAndUpgradeKeepsLinkName(t *testing.T) {
+	tmpDir := t.TempDir()
+	setTestOptions(t, tmpDir)
+	latest := map[string]string{"owner/tool": "v1.0.0"}
+	newTestReleaseServer(t, latest)
+
+	captureStderr(t, func() {
+		captureStdout(t, func() {
+			if err := handleInstall("owner", "tool", "", installSpec{linkName: "mytool"}); err != nil {
+				t.Fatalf("handleInstall: %v", err)
+			}
+		})
+	})
+
+	if _, err := os.Lstat(filepath.Join(tmpDir, "bin", "tool")); !os.IsNotExist(err) {
+		t.Fatalf("default link name should not be created, lstat err=%v", err)
+	}
+
+	latest["owner/tool"] = "v2.0.0"
+	captureStderr(t, func() {
+		captureStdout(t, func() {
+			if err := upgradeInstalled(tmpDir); err != nil {
+				t.Fatalf("upgradeInstalled: %v", err)
+			}
+		})
+	})
+
+	target, err := os.Readlink(filepath.Join(tmpDir, "bin", "mytool"))
+	if err != nil {
+		t.Fatalf("Readlink mytool: %v", err)
+	}
+
+	want := filepath.Join(tmpDir, "ghinst", "owner", "tool@"+encodeTagForPath("v2.0.0"), "tool")
+	if target != want {
+		t.Fatalf("mytool target = %q, want %q", target, want)
+	}
+
+	captureStdout(t, func() {
+		if err := useVersion(tmpDir, "owner", "tool", "v1.0.0"); err != nil {
+			t.Fatalf("useVersion: %v", err)
+		}
+	})
+
+	target, err = os.Readlink(filepath.Join(tmpDir, "bin", "mytool"))
+	if err != nil {
+		t.Fatalf("Readlink mytool after -use: %v", err)
+	}
+
+	if want := filepath.Join(tmpDir, "ghinst", "owner", "tool@"+encodeTagForPath("v1.0.0"), "tool"); target != want {
+		t.Fatalf("mytool target after -use = %q, want %q", target, want)
+	}
+
+	if _, err := os.Lstat(filepath.Join(tmpDir, "bin", "tool")); !os.IsNotExist(err) {
+		t.Fatalf("-use should keep the custom link name, lstat err=%v", err)
+	}
+}
+
+func TestValidateOptionsRejectsInvalidLinkName(t *testing.T) {
+	oldOptions := options
+	t.Cleanup(func() { options = oldOptions })
+
+	options.baseDir = t.TempDir()
+	options.maxSize = 1
+	options.jobs = 1
+	options.httpTimeout = time.Second
+	options.linkName = "../tool"
+
+	if err := validateOptions(); err == nil || !strings.Contains(err.Error(), "invalid link name") {
+		t.Fatalf("validateOptions error = %v, want invalid link name", err)
+	}
+}
+
+func TestSelectReleaseAssetWithPattern(t *testing.T) {
+	platform := runtime.GOOS + "_" + runtime.GOARCH
+	assets := []Asset{
+		{Name: "tool_" + platform + ".tar.gz"},
+		{Name: "tool_" + platform + "_musl.tar.gz"},
+		{Name: "tool_plan9_mips.tar.gz"},
+		{Name: "tool_plan9_mips_static.tar.gz"},
+	}
+
+	tests := []struct {
+		pattern string
+		want    string
+		wantErr string
+	}{
+		{pattern: "", want: "tool_" + platform + ".tar.gz"},
+		{pattern: "*musl*", want: "tool_" + platform + "_musl.tar.gz"},
+		{pattern: "/_static/", want: "tool_plan9_mips_static.tar.gz"},
+		{pattern: "tool_" + platform + "*", want: "tool_" + platform + ".tar.gz"},
+		{pattern: "*.zip", wantErr: `no asset matches "*.zip"`},
+		{pattern: "*plan9*", wantErr: `asset pattern "*plan9*" matches 2 assets`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.pattern, func(t *testing.T) {
+			got, err := selectReleaseAsset(assets, installSpec{assetPattern: tc.pattern})
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("selectReleaseAsset error = %v, want %q", err, tc.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("selectReleaseAsset: %v", err)
+			}
+
+			if got.Name != tc.want {
+				t.Fatalf("selectReleaseAsset = %q, want %q", got.Name, tc.want)
+			}
+		})
+	}
+}
+
+func TestHandleInstallOutputDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	setTestOptions(t, tmpDir)
+	newTestReleaseServer(t, map[string]string{"owner/tool": "v1.0.0"})
+
+	outDir := filepath.Join(t.TempDir(), "out")
+	captureStderr(t, func() {
+		captureStdout(t, func() {
+			if err := handleInstall("owner", "tool", "", installSpec{outputDir: outDir}); err != nil {
+				t.Fatalf("handleInstall: %v", err)
+			}
+		})
+	})
+
+	info, err := os.Stat(filepath.Join(outDir, "tool"))
+	if err != nil {
+		t.Fatalf("Stat output binary: %v", err)
+	}
+
+	if info.Mode().Perm()&0111 == 0 {
+		t.Fatalf("output binary mode = %v, want executable", info.Mode())
+	}
+
+	for _, dir := range []string{"bin", "ghinst"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, dir)); !os.IsNotExist(err) {
+			t.Fatalf("-output should not touch %s, stat err=%v", dir, err)
+		}
+	}
+}
+
+func TestSelectReleaseAssetForOtherPlatform(t *testing.T) {
+	assets := []Asset{
+		{Name: "tool_linux_amd64.tar.gz"},
+		{Name: "tool_linux_arm64.tar.gz"},
+		{Name: "tool_darwin_arm64.tar.gz"},
+	}
+
+	got, err := selectReleaseAsset(assets, installSpec{goos: "darwin", goarch: "arm64"})
+	if err != nil {
+		t.Fatalf("selectReleaseAsset: %v", err)
+	}
+
+	if got.Name != "tool_darwin_arm64.tar.gz" {
+		t.Fatalf("selectReleaseAsset = %q, want tool_darwin_arm64.tar.gz", got.Name)
+	}
+}
+
+func TestValidateOptionsRejectsCosignKeyWithFromFile(t *testing.T) {
+	oldOptions := options
+	t.Cleanup(func() { options = oldOptions })
+
+	options.baseDir = t.TempDir()
+	options.maxSize = 1
+	options.jobs = 1
+	options.httpTimeout = time.Second
+	options.fromFile = "tool.tar.gz"
+	options.cosignKey = "cosign.pub"
+
+	// A local file has no release signatures to check.
+	if err := validateOptions(); err == nil || !strings.Contains(err.Error(), "-cosign-key cannot be combined with -from-file") {
+		t.Fatalf("validateOptions error = %v, want -cosign-key rejected", err)
+	}
+}
+
+func TestValidateOptionsPlatformOverride(t *testing.T) {
+	oldOptions := options
+	t.Cleanup(func() { options = oldOptions })
+
+	tests := []struct {
+		goos, goarch, output string
+		wantErr              string
+	}{
+		{goos: "linux", goarch: "arm64", wantErr: "require -output"},
+		{goos: "plan9", output: "out", wantErr: "unsupported OS"},
+		{goarch: "sparc", output: "out", wantErr: "unsupported architecture"},
+		{goos: "linux", goarch: "riscv64", output: "out"},
+	}
+
+	for _, tc := range tests {
+		options.baseDir = t.TempDir()
+		options.maxSize = 1
+		options.jobs = 1
+		options.httpTimeout = time.Second
+		options.goos, options.goarch, options.output = tc.goos, tc.goarch, tc.output
+
+		err := validateOptions()
+		if tc.wantErr == "" {
+			if err != nil {
+				t.Errorf("validateOptions(%s/%s) = %v", tc.goos, tc.goarch, err)
+			}
+
+			continue
+		}
+
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("validateOptions(%s/%s) error = %v, want %q", tc.goos, tc.goarch, err, tc.wantErr)
+		}
+	}
+}
+
+func TestDownloadOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	setTestOptions(t, tmpDir)
+	newTestReleaseServer(t, map[string]string{"owner/tool": "v1.0.0"})
+
+	outDir := filepath.Join(t.TempDir(), "mirror")
+	captureStderr(t, func() {
+		captureStdout(t, func() {
+			if err := downloadOnly("owner", "tool", "", installSpec{outputDir: outDir}); err != nil {
+				t.Fatalf("downloadOnly: %v", err)
+			}
+		})
+	})
+
+	assetPath := filepath.Join(outDir, testAssetName())
+	f, err := os.Open(assetPath)
+	if err != nil {
+		t.Fatalf("Open asset: %v", err)
+	}
+	defer f.Close()
+
+	digest, err := fileDigest(f)
+	if err != nil {
+		t.Fatalf("fileDigest: %v", err)
+	}
+
+	sum, err := os.ReadFile(assetPath + ".sha256")
+	if err != nil {
+		t.Fatalf("ReadFile sidecar: %v", err)
+	}
+
+	if want := strings.TrimPrefix(digest, "sha256:") + "  " + testAssetName() + "\n"; string(sum) != want {
+		t.Fatalf("sidecar = %q, want %q", sum, want)
+	}
+
+	for _, dir := range []string{"bin", "ghinst"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, dir)); !os.IsNotExist(err) {
+			t.Fatalf("-download-only should not touch %s, stat err=%v", dir, err)
+		}
+	}
+}
+
+func TestInstallFromFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	setTestOptions(t, tmpDir)
+
+	data, err := buildTarGz([]struct {
+		name string
+		mode int64
+		body []byte
+	}{{"tool", 0755, []byte("#!/bin/sh\necho v1.2.3\n")}})
+	if err != nil {
+		t.Fatalf("buildTarGz: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "tool_linux_amd64.tar.gz")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	sum := sha256.Sum256(data)
+	wrong := strings.Repeat("0", 64)
+	err = installFromFile("owner", "tool", "v1.2.3", path, wrong, installSpec{})
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("installFromFile with wrong sha256 error = %v, want checksum mismatch", err)
+	}
+
+	if _, err := os.Lstat(filepath.Join(tmpDir, "bin", "tool")); !os.IsNotExist(err) {
+		t.Fatalf("failed install should not create a link, lstat err=%v", err)
+	}
+
+	captureStdout(t, func() {
+		if err := installFromFile("owner", "tool", "v1.2.3", path, hex.EncodeToString(sum[:]), installSpec{}); err != nil {
+			t.Fatalf("installFromFile: %v", err)
+		}
+	})
+
+	target, err := os.Readlink(filepath.Join(tmpDir, "bin", "tool"))
+	if err != nil {
+		t.Fatalf("Readlink: %v", err)
+	}
+
+	if want := filepath.Join(tmpDir, "ghinst", "owner", "tool@"+encodeTagForPath("v1.2.3"), "tool"); target != want {
+		t.Fatalf("link target = %q, want %q", target, want)
+	}
+}
+
+func TestInstallFromFileRefusesRepoWithTrustedKeys(t *testing.T) {
+	setTestOptions(t, t.TempDir())
+	setTestTrustedKeys(t, map[string]*repoKeys{"owner/tool": {}})
+
+	path := filepath.Join(t.TempDir(), "tool_linux_amd64.tar.gz")
+	if err := os.WriteFile(path, []byte("asset"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	err := installFromFile("Owner", "Tool", "v1.2.3", path, "", installSpec{})
+	if err == nil || !strings.Contains(err.Error(), "cannot verify its signed checksums") {
+		t.Fatalf("installFromFile error = %v, want repo with trusted keys refused", err)
+	}
+}
+
+func TestInstallTargets(t *testing.T) {
+	tmpDir := t.TempDir()
+	setTestOptions(t, tmpDir)
+	newTestReleaseServer(t, map[string]string{
+		"owner/one":   "v1.0.0",
+		"owner/two":   "v2.0.0",
+		"owner/three": "v3.0.0",
+	})
+
+	var err error
+	stderr := captureStderr(t, func() {
+		captureStdout(t, func() {
+			err = installTargets([]string{"owner/one", "owner/two@v2.0.0", "owner/missing", "owner/three"}, installSpec{}, 2)
+		})
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "1 of 4 installs failed: owner/missing") {
+		t.Fatalf("installTargets error = %v, want owner/missing to fail", err)
+	}
+
+	if !strings.Contains(stderr, "error: owner/missing:") {
+		t.Fatalf("stderr = %q, want the owner/missing error", stderr)
+	}
+
+	for _, name := range []string{"one", "two", "three"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, "bin", name)); err != nil {
+			t.Errorf("bin/%s: %v", name, err)
+		}
+	}
+}
+
+func TestInstallTargetsSameBinaryName(t *testing.T) {
+	tmpDir := t.TempDir()
+	setTestOptions(t, tmpDir)
+	newTestReleaseServer(t, map[string]string{
+		"alice/tool": "v1.0.0",
+		"bob/tool":   "v2.0.0",
+	})
+
+	// Both releases ship bin/tool; whichever links it first keeps it.
+	var err error
+	stderr := captureStderr(t, func() {
+		captureStdout(t, func() {
+			err = installTargets([]string{"alice/tool", "bob/tool"}, installSpec{}, 2)
+		})
+	})
+
+	if err == nil || !strings.Contains(stderr, "is linked to") {
+		t.Fatalf("installTargets error = %v, stderr = %q; want a link conflict", err, stderr)
+	}
+
+	target, err := os.Readlink(filepath.Join(tmpDir, "bin", "tool"))
+	if err != nil {
+		t.Fatalf("Readlink: %v", err)
+	}
+
+	winner := linkedRepo(tmpDir, target)
+	for _, repo := range []string{"alice/tool", "bob/tool"} {
+		owner, _, _ := strings.Cut(repo, "/")
+		dirs, _ := filepath.Glob(filepath.Join(tmpDir, "ghinst", owner, "tool@*"))
+		if repo != winner {
+			if len(dirs) != 0 {
+				t.Errorf("%s lost the link but kept install dirs %v", repo, dirs)
+			}
+
+			continue
+		}
+
+		if len(dirs) != 1 {
+			t.Fatalf("%s install dirs = %v, want one", repo, dirs)
+		}
+
+		rec, ok, err := readInstallRecord(dirs[0])
+		if err != nil || !ok || rec.Links["tool"] != "tool" {
+			t.Errorf("%s install record = %+v, %v, %v; want it to own bin/tool", repo, rec, ok, err)
+		}
+	}
+}
+
+func TestInstallTargetsRejectsDuplicates(t *testing.T) {
+	err := installTargets([]string{"owner/tool", "owner/tool@v1.0.0"}, installSpec{}, 2)
+	if err == nil || !strings.Contains(err.Error(), "listed more than once") {
+		t.Fatalf("installTargets error = %v, want duplicate error", err)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// manifest is a ghinst.toml file listing the tools to install:
+//
+//	[[tool]]
+//	repo = "junegunn/fzf@v0.54.0"
+//	asset = "fzf-*-linux_amd64.tar.gz" # optional glob or /regexp/
+//	bin = "fzf"                       # optional name or glob
+//	bins = ["fzf", "fzf-*"]           # optional, or all = true
+type manifest struct {
+	Tools []manifestTool `toml:"tool"`
+}
+
+type manifestTool struct {
+	Repo  string   `toml:"repo"`
+	Asset string   `toml:"asset"`
+	Bin   string   `toml:"bin"`
+	Bins  []string `toml:"bins"`
+	All   bool     `toml:"all"`
+}
+
+func (t manifestTool) spec() installSpec {
+	names := t.Bins
+	if t.Bin != "" {
+		names = append([]string{t.Bin}, names...)
+	}
+
+	return installSpec{assetPattern: t.Asset, bins: binarySelection{names: names, all: t.All}}
+}
+
+func loadManifest(path string) (manifest, error) {
+	var m manifest
+	md, err := toml.DecodeFile(path, &m)
+	if err != nil {
+		return manifest{}, fmt.Errorf("reading manifest: %w", err)
+	}
+
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, k := range undecoded {
+			keys[i] = k.String()
+		}
+
+		return manifest{}, fmt.Errorf("reading manifest: unknown keys %s", strings.Join(keys, ", "))
+	}
+
+	seen := map[string]bool{}
+	for i, t := range m.Tools {
+		owner, repo, _, err := parseTarget(t.Repo)
+		if err != nil {
+			return manifest{}, fmt.Errorf("manifest tool %d: %w", i+1, err)
+		}
+
+		if t.Asset != "" {
+			if _, err := assetMatcher(t.Asset); err != nil {
+				return manifest{}, fmt.Errorf("manifest tool %d: %w", i+1, err)
+			}
+		}
+
+		for _, bin := range t.spec().bins.names {
+			if err := validateBinaryPattern(bin); err != nil {
+				return manifest{}, fmt.Errorf("manifest tool %d: %w", i+1, err)
+			}
+		}
+
+		key := owner + "/" + repo
+		if seen[key] {
+			return manifest{}, fmt.Errorf("manifest lists %s more than once", key)
+		}
+
+		seen[key] = true
+	}
+
+	return m, nil
+}
+
+// installManifest installs every tool listed in the manifest at path and
+// records what each resolved to in the lockfile next to it. With -locked it
+// installs from the lockfile instead. Pinned versions that are already
+// installed and locked are skipped without contacting GitHub. A failure on
+// one tool does not stop the others.
+func installManifest(path string) error {
+	m, err := loadManifest(path)
+	if err != nil {
+		return err
+	}
+
+	lockPath := lockfilePath(path)
+	lock, err := loadLockfile(lockPath)
+	if err != nil {
+		return err
+	}
+
+	if options.locked {
+		return installLocked(m, lock)
+	}
+
+	platform := currentPlatform()
+	entries := make([]lockEntry, 0, len(m.Tools))
+	failed := 0
+	for _, t := range m.Tools {
+		owner, repo, _, _ := parseTarget(t.Repo)
+		prev, _ := lock.find(owner+"/"+repo, platform)
+
+		entry, err := installManifestTool(t, prev)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s: %v\n", t.Repo, err)
+			failed++
+			if prev.Tag != "" {
+				entries = append(entries, prev)
+			}
+
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	lock.update(m, platform, entries)
+	if err := writeLockfile(lockPath, lock); err != nil {
+		return fmt.Errorf("writing lockfile: %w", err)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d manifest installs failed", failed, len(m.Tools))
+	}
+
+	return nil
+}
+
+// installManifestTool installs a single manifest entry and returns its
+// lockfile entry. prev is the entry currently locked for it, if any.
+func installManifestTool(t manifestTool, prev lockEntry) (lockEntry, error) {
+	owner, repo, tag, err := parseTarget(t.Repo)
+	if err != nil {
+		return lockEntry{}, err
+	}
+
+	spec := t.spec()
+	if tag != "" && tag == prev.Tag {
+		installNeeded, err := ensureInstallNeeded(owner, repo, tag)
+		if err != nil {
+			return lockEntry{}, err
+		}
+
+		if !installNeeded {
+			return prev, nil
+		}
+	}
+
+	release, err := fetchRelease(owner, repo, tag)
+	if err != nil {
+		return lockEntry{}, err
+	}
+
+	installNeeded, err := ensureInstallNeeded(owner, repo, release.TagName)
+	if err != nil {
+		return lockEntry{}, err
+	}
+
+	if !installNeeded {
+		if prev.Tag == release.TagName {
+			return prev, nil
+		}
+
+		asset, err := lockedAsset(release, spec)
+		if err != nil {
+			return lockEntry{}, err
+		}
+
+		return newLockEntry(owner, repo, release.TagName, asset), nil
+	}
+
+	linkPaths, asset, err := installRelease(owner, repo, release, spec)
+	if err != nil {
+		return lockEntry{}, err
+	}
+
+	printInstalled(repo, release.TagName, linkPaths)
+	return newLockEntry(owner, repo, release.TagName, asset), nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeTestManifest(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "ghinst.toml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile manifest: %v", err)
+	}
+
+	return path
+}
+
+func TestLoadManifest(t *testing.T) {
+	path := writeTestManifest(t, `
+[[tool]]
+repo = "junegunn/fzf@v0.54.0"
+
+[[tool]]
+repo = "owner/tool"
+asset = "*_linux_amd64.tar.gz"
+bin = "tool"
+`)
+
+	m, err := loadManifest(path)
+	if err != nil {
+		t.Fatalf("loadManifest: %v", err)
+	}
+
+	want := []manifestTool{
+		{Repo: "junegunn/fzf@v0.54.0"},
+		{Repo: "owner/tool", Asset: "*_linux_amd64.tar.gz", Bin: "tool"},
+	}
+	if len(m.Tools) != len(want) {
+		t.Fatalf("tools = %v, want %v", m.Tools, want)
+	}
+
+	for i := range want {
+		if !reflect.DeepEqual(m.Tools[i], want[i]) {
+			t.Errorf("tool %d = %+v, want %+v", i, m.Tools[i], want[i])
+		}
+	}
+}
+
+func TestLoadManifestRejectsInvalidEntries(t *testing.T) {
+	tests := map[string]struct {
+		content string
+		wantErr string
+	}{
+		"unknown key": {
+			content: "[[tool]]\nrepo = \"owner/repo\"\nversion = \"v1\"\n",
+			wantErr: "unknown keys tool.version",
+		},
+		"bad target": {
+			content: "[[tool]]\nrepo = \"owner\"\n",
+			wantErr: "manifest tool 1",
+		},
+		"bad bin": {
+			content: "[[tool]]\nrepo = \"owner/repo\"\nbin = \"../tool\"\n",
+			wantErr: "invalid binary name",
+		},
+		"duplicate": {
+			content: "[[tool]]\nrepo = \"owner/repo@v1\"\n\n[[tool]]\nrepo = \"owner/repo@v2\"\n",
+			wantErr: "lists owner/repo more than once",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := loadManifest(writeTestManifest(t, tc.content))
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("loadManifest error = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestInstallManifest(t *testing.T) {
+	tmpDir := t.TempDir()
+	setTestOptions(t, tmpDir)
+	newTestReleaseServer(t, map[string]string{
+		"owner/pinned": "v3.0.0",
+		"owner/latest": "v2.0.0",
+	})
+
+	installTestVersion(t, tmpDir, "owner", "pinned", "v1.0.0")
+
+	path := writeTestManifest(t, `
+[[tool]]
+repo = "owner/pinned@v1.0.0"
+
+[[tool]]
+repo = "owner/latest"
+asset = "tool_*"
+`)
+
+	out := captureStdout(t, func() {
+		if err := installManifest(path); err != nil {
+			t.Fatalf("installManifest: %v", err)
+		}
+	})
+
+	if !strings.Contains(out, "owner/pinned is already at v1.0.0") {
+		t.Errorf("pinned install should be skipped, output:\n%s", out)
+	}
+
+	if !strings.Contains(out, "installed latest (v2.0.0)") {
+		t.Errorf("latest install missing from output:\n%s", out)
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, "ghinst", "owner", "latest@"+encodeTagForPath("v2.0.0"), "latest")); err != nil {
+		t.Fatalf("owner/latest should be installed: %v", err)
+	}
+}
+
+func TestInstallManifestReportsFailures(t *testing.T) {
+	tmpDir := t.TempDir()
+	setTestOptions(t, tmpDir)
+	newTestReleaseServer(t, map[string]string{"owner/ok": "v1.0.0"})
+
+	path := writeTestManifest(t, `
+[[tool]]
+repo = "owner/missing"
+
+[[tool]]
+repo = "owner/ok"
+bin = "nope"
+`)
+
+	var err error
+	stderr := captureStderr(t, func() {
+		captureStdout(t, func() {
+			err = installManifest(path)
+		})
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "2 of 2 manifest installs failed") {
+		t.Fatalf("installManifest error = %v, want 2 of 2 failed", err)
+	}
+
+	if !strings.Contains(stderr, `no file matching "nope" found in archive`) {
+		t.Fatalf("stderr missing bin error:\n%s", stderr)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// minisignKey is a minisign public key, as found on the second line of a
+// minisign.pub file.
+type minisignKey struct {
+	id  [8]byte
+	key ed25519.PublicKey
+}
+
+func parseMinisignKey(s string) (minisignKey, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(b) != 2+8+ed25519.PublicKeySize || string(b[:2]) != "Ed" {
+		return minisignKey{}, fmt.Errorf("invalid minisign public key %q", s)
+	}
+
+	var k minisignKey
+	copy(k.id[:], b[2:10])
+	k.key = ed25519.PublicKey(b[10:])
+	return k, nil
+}
+
+// verifyMinisign checks a .minisig signature file over data: the signature
+// itself, over the data or its BLAKE2b-512 hash for prehashed ("ED")
+// signatures, and the global signature binding the trusted comment to it.
+func verifyMinisign(keys []minisignKey, data, sigFile []byte) error {
+	lines := strings.Split(strings.ReplaceAll(string(sigFile), "\r\n", "\n"), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[0], "untrusted comment:") {
+		return errors.New("invalid minisign signature")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(sig) != 2+8+ed25519.SignatureSize {
+		return errors.New("invalid minisign signature")
+	}
+
+	comment, ok := strings.CutPrefix(lines[2], "trusted comment: ")
+	if !ok {
+		return errors.New("invalid minisign trusted comment")
+	}
+
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return errors.New("invalid minisign global signature")
+	}
+
+	msg := data
+	switch string(sig[:2]) {
+	case "Ed":
+	case "ED":
+		sum := blake2b.Sum512(data)
+		msg = sum[:]
+	default:
+		return fmt.Errorf("unsupported minisign algorithm %q", sig[:2])
+	}
+
+	for _, k := range keys {
+		if !bytes.Equal(k.id[:], sig[2:10]) {
+			continue
+		}
+
+		if !ed25519.Verify(k.key, msg, sig[10:]) {
+			return errors.New("minisign signature does not match")
+		}
+
+		if !ed25519.Verify(k.key, append(bytes.Clone(sig[10:]), comment...), global) {
+			return errors.New("minisign trusted comment signature does not match")
+		}
+
+		return nil
+	}
+
+	// minisign shows key IDs as little-endian integers.
+	return fmt.Errorf("minisign signature made with untrusted key %016X", binary.LittleEndian.Uint64(sig[2:10]))
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// progressInterval is the minimum time between progress line redraws.
+const progressInterval = 100 * time.Millisecond
+
+// showProgress turns download progress on; main sets it from -quiet and
+// whether several targets download at once.
+var showProgress = false
+
+// progressOutput returns where download progress is drawn: stderr when it is
+// a terminal and progress is on, nil otherwise.
+var progressOutput = func() io.Writer {
+	if !showProgress {
+		return nil
+	}
+
+	info, err := os.Stderr.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+
+	return os.Stderr
+}
+
+// downloadProgress draws a single self-overwriting line with the bytes
+// received, the total when known, the transfer rate and the time left.
+type downloadProgress struct {
+	w     io.Writer
+	name  string
+	total int64 // 0 when unknown
+	done  int64
+	start time.Time
+	drawn time.Time
+}
+
+func newDownloadProgress(w io.Writer, name string, total int64) *downloadProgress {
+	return &downloadProgress{w: w, name: name, total: total, start: time.Now()}
+}
+
+// reader wraps r so that reading from it advances the progress.
+func (p *downloadProgress) reader(r io.Reader) io.Reader {
+	if p == nil {
+		return r
+	}
+
+	return progressReader{r, p}
+}
+
+// reset sets the received byte count, as when a download starts over.
+func (p *downloadProgress) reset(done int64) {
+	if p != nil {
+		p.done = done
+	}
+}
+
+// setTotal records the download size when it was not known up front.
+func (p *downloadProgress) setTotal(total int64) {
+	if p != nil && p.total <= 0 {
+		p.total = total
+	}
+}
+
+func (p *downloadProgress) add(n int) {
+	p.done += int64(n)
+	if now := time.Now(); now.Sub(p.drawn) >= progressInterval {
+		p.drawn = now
+		fmt.Fprintf(p.w, "\r\033[K%s", p.line(now))
+	}
+}
+
+// finish draws the final state and ends the line.
+func (p *downloadProgress) finish() {
+	if p != nil {
+		fmt.Fprintf(p.w, "\r\033[K%s\n", p.line(time.Now()))
+	}
+}
+
+func (p *downloadProgress) line(now time.Time) string {
+	line := p.name + "  " + formatBytes(p.done)
+	if p.total > 0 {
+		line += " / " + formatBytes(p.total)
+	}
+
+	elapsed := now.Sub(p.start).Seconds()
+	if elapsed <= 0 {
+		return line
+	}
+
+	rate := float64(p.done) / elapsed
+	line += "  " + formatBytes(int64(rate)) + "/s"
+	if p.total > p.done && rate > 0 {
+		eta := time.Duration(float64(p.total-p.done) / rate * float64(time.Second))
+		line += "  ETA " + eta.Round(time.Second).String()
+	}
+
+	return line
+}
+
+type progressReader struct {
+	r io.Reader
+	p *downloadProgress
+}
+
+func (r progressReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	r.p.add(n)
+	return n, err
+}
+
+// formatBytes renders n in binary units, e.g. 1.5 MiB.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownloadProgressLine(t *testing.T) {
+	start := time.Now()
+	p := &downloadProgress{name: "tool.tar.gz", total: 4 << 20, done: 1 << 20, start: start}
+
+	got := p.line(start.Add(2 * time.Second))
+	want := "tool.tar.gz  1.0 MiB / 4.0 MiB  512.0 KiB/s  ETA 6s"
+	if got != want {
+		t.Fatalf("line = %q, want %q", got, want)
+	}
+
+	p.total = 0
+	if got := p.line(start.Add(2 * time.Second)); strings.Contains(got, "ETA") || strings.Contains(got, " / ") {
+		t.Fatalf("line without total = %q, want no total or ETA", got)
+	}
+}
+
+func TestDownloadShowsProgress(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 3000)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	old := progressOutput
+	t.Cleanup(func() { progressOutput = old })
+
+	for _, w := range []io.Writer{&out, nil} {
+		progressOutput = func() io.Writer { return w }
+		tmp, err := download(srv.URL+"/tool.tar.gz", int64(len(data)), 1<<20, true)
+		if err != nil {
+			t.Fatalf("download: %v", err)
+		}
+
+		readDownload(t, tmp)
+	}
+
+	if got := out.String(); !strings.HasSuffix(got, "\n") || !strings.Contains(got, "tool.tar.gz  2.9 KiB / 2.9 KiB") {
+		t.Fatalf("progress output = %q, want a finished line for tool.tar.gz", got)
+	}
+
+	if strings.Count(out.String(), "\n") != 1 {
+		t.Fatalf("progress output = %q, want output from the first download only", out.String())
+	}
+}
+
+func TestFetchReleaseFileShowsNoProgress(t *testing.T) {
+	var out bytes.Buffer
+	old := progressOutput
+	t.Cleanup(func() { progressOutput = old })
+	progressOutput = func() io.Writer { return &out }
+
+	assets := serveReleaseFiles(t, map[string][]byte{"checksums.txt": []byte("sums")})
+	if _, err := fetchReleaseFile(assets[0], 1<<20); err != nil {
+		t.Fatalf("fetchReleaseFile: %v", err)
+	}
+
+	if out.Len() != 0 {
+		t.Fatalf("progress output = %q, want none for a release file", out.String())
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1536, "1.5 KiB"},
+		{200 << 20, "200.0 MiB"},
+		{3 << 30, "3.0 GiB"},
+	}
+
+	for _, tc := range tests {
+		if got := formatBytes(tc.n); got != tc.want {
+			t.Errorf("formatBytes(%d) = %q, want %q", tc.n, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Scores added to or subtracted from an asset's rank. Only assets that match
+// the OS and architecture and are in an installable format are eligible; the
+// rest of the score orders eligible assets, with shorter names breaking ties.
+const (
+	scoreArchive   = 10
+	scoreRaw       = -20 // raw binaries only win when no archive is eligible
+	scoreUniversal = -1
+	scorePreferred = 4 // libc or static build preferred on this host
+	scoreLibcOK    = 2 // libc that runs on this host
+	scoreArmExact  = 2 // ARM build for exactly this GOARM level
+	scoreArmOlder  = 1 // ARM build for an older level this host still runs
+	scoreWrongLibc = -4
+	scoreDebug     = -5
+	scoreSource    = -10
+)
+
+var (
+	// Suffixes of release files that are metadata about other assets.
+	metadataExts = []string{
+		".sha256", ".sha512", ".sha1", ".md5", ".sum", ".sig", ".asc", ".pem",
+		".cert", ".crt", ".minisig", ".bundle", ".sbom", ".spdx", ".json",
+		".jsonl", ".txt", ".pub",
+	}
+
+	// Suffixes of OS package formats ghinst cannot install from.
+	packageExts = []string{".deb", ".rpm", ".apk", ".msi", ".pkg", ".dmg", ".snap", ".flatpak"}
+
+	debugWords  = []string{"debug", "dbg", "dsym", "symbols"}
+	sourceWords = []string{"src", "source", "sources", "vendor", "vendored"}
+)
+
+// scoredAsset is an asset with its rank for a platform and the reasons that
+// make up the score, as shown by -explain.
+type scoredAsset struct {
+	Asset    Asset
+	Score    int
+	Eligible bool
+	Reasons  []string
+}
+
+// rankAssets scores every asset for goos/goarch on a host with the given
+// libc ("musl", "gnu" or "" when unknown) and returns them best first.
+func rankAssets(assets []Asset, goos, goarch, libc string) []scoredAsset {
+	ranked := make([]scoredAsset, 0, len(assets))
+	for _, a := range assets {
+		ranked = append(ranked, scoreAsset(a, goos, goarch, libc))
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Eligible != b.Eligible {
+			return a.Eligible
+		}
+
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+
+		if len(a.Asset.Name) != len(b.Asset.Name) {
+			return len(a.Asset.Name) < len(b.Asset.Name)
+		}
+
+		return a.Asset.Name < b.Asset.Name
+	})
+
+	return ranked
+}
+
+func scoreAsset(a Asset, goos, goarch, libc string) scoredAsset {
+	s := scoredAsset{Asset: a, Eligible: true}
+	lower := strings.ToLower(a.Name)
+	add := func(score int, reason string) {
+		s.Score += score
+		s.Reasons = append(s.Reasons, fmt.Sprintf("%+d %s", score, reason))
+	}
+	reject := func(reason string) {
+		s.Eligible = false
+		s.Reasons = append(s.Reasons, reason)
+	}
+
+	if ext := matchingSuffix(lower, metadataExts); ext != "" {
+		reject("checksum, signature or metadata file (" + ext + ")")
+	} else if ext := matchingSuffix(lower, packageExts); ext != "" {
+		reject("OS package (" + ext + ")")
+	} else if isArchive(lower) {
+		add(scoreArchive, "archive")
+	} else if isRawBinary(lower, goos) {
+		add(scoreRaw, "raw binary")
+	} else {
+		reject("not an archive or binary")
+	}
+
+	if !hasWordPrefix(lower, osAliases[goos]) {
+		reject("no " + goos + " in name")
+	}
+
+	switch {
+	case hasWord(lower, archAliases[goarch]):
+	case goos == "darwin" && hasWord(lower, []string{"universal", "all"}):
+		add(scoreUniversal, "universal macOS build")
+	default:
+		reject("no " + goarch + " in name")
+	}
+
+	if goarch == "arm" {
+		if v := assetArmVersion(lower); v > 0 {
+			goarm := hostGOARM()
+			switch {
+			case v > goarm:
+				reject(fmt.Sprintf("needs armv%d, host is armv%d", v, goarm))
+			case v == goarm:
+				add(scoreArmExact, fmt.Sprintf("armv%d build", v))
+			default:
+				add(scoreArmOlder, fmt.Sprintf("armv%d build runs on armv%d", v, goarm))
+			}
+		}
+	}
+
+	musl := hasWordPrefix(lower, []string{"musl"})
+	gnu := hasWordPrefix(lower, []string{"gnu", "glibc"})
+	static := hasWordPrefix(lower, []string{"static"})
+	switch libc {
+	case "musl":
+		switch {
+		case musl:
+			add(scorePreferred, "musl build on musl host")
+		case static:
+			add(scorePreferred, "static build on musl host")
+		case gnu:
+			add(scoreWrongLibc, "glibc build on musl host")
+		}
+	case "gnu":
+		if gnu {
+			add(scoreLibcOK, "glibc build on glibc host")
+		}
+	}
+
+	if hasWordPrefix(lower, debugWords) {
+		add(scoreDebug, "debug build")
+	}
+
+	if hasWordPrefix(lower, sourceWords) {
+		add(scoreSource, "source archive")
+	}
+
+	return s
+}
+
+// printAssetRanking writes the top candidates of ranked to w.
+func printAssetRanking(w io.Writer, ranked []scoredAsset, goos, goarch, libc string, limit int) {
+	platform := goos + "/" + goarch
+	if libc != "" {
+		platform += " (" + libc + ")"
+	}
+
+	fmt.Fprintf(w, "asset candidates for %s:\n", platform)
+	for i, s := range ranked {
+		if i == limit {
+			fmt.Fprintf(w, "  ... %d more\n", len(ranked)-limit)
+			break
+		}
+
+		marker, score := " ", fmt.Sprintf("%4d", s.Score)
+		if !s.Eligible {
+			score = "   -"
+		} else if i == 0 {
+			marker = "*"
+		}
+
+		fmt.Fprintf(w, "%s %s  %s  [%s]\n", marker, score, s.Asset.Name, strings.Join(s.Reasons, ", "))
+	}
+}
+
+// hostLibc reports the C library of the running Linux host, "musl" or "gnu".
+// It is empty for other targets, where it does not influence selection.
+var hostLibc = func(goos string) string {
+	if goos != "linux" || runtime.GOOS != "linux" {
+		return ""
+	}
+
+	if matches, _ := filepath.Glob("/lib/ld-musl-*.so.1"); len(matches) > 0 {
+		return "musl"
+	}
+
+	return "gnu"
+}
+
+// isRawBinary reports whether the lower-cased asset name looks like a bare
+// executable: no extension, .bin or .AppImage, or .exe on Windows. A "dot"
+// inside a version such as tool-1.2.3-linux-amd64 is not an extension.
+func isRawBinary(name, goos string) bool {
+	switch ext := path.Ext(name); ext {
+	case "", ".bin", ".appimage":
+		return true
+	case ".exe":
+		return goos == "windows"
+	default:
+		return strings.ContainsAny(ext, "-_")
+	}
+}
+
+// assetArmVersion returns the highest ARM level named in the asset, or 0
+// when the name only says "arm".
+func assetArmVersion(name string) int {
+	v := 0
+	for alias, level := range armVersions {
+		if level > v && hasWord(name, []string{alias}) {
+			v = level
+		}
+	}
+
+	return v
+}
+
+// hostGOARM is the ARM level of 32-bit ARM targets: the GOARM ghinst was
+// built with, or 7, Go's default, when that is not recorded.
+var hostGOARM = func() int {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key != "GOARM" {
+				continue
+			}
+
+			// GOARM may carry a float ABI suffix, e.g. "7,softfloat".
+			level, _, _ := strings.Cut(s.Value, ",")
+			if v, err := strconv.Atoi(level); err == nil {
+				return v
+			}
+		}
+	}
+
+	return 7
+}
+
+func matchingSuffix(s string, suffixes []string) string {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return suffix
+		}
+	}
+
+	return ""
+}
+
+// hasWord reports whether any of words occurs in s delimited by
+// non-alphanumeric characters or the ends of s, so "arm" does not match
+// "arm64" and "win" does not match "darwin".
+func hasWord(s string, words []string) bool {
+	return containsWord(s, words, true)
+}
+
+// hasWordPrefix is like hasWord but only requires the word to start at a
+// boundary, so "gnu" matches "gnueabihf" and "win" matches "win64".
+func hasWordPrefix(s string, words []string) bool {
+	return containsWord(s, words, false)
+}
+
+func containsWord(s string, words []string, whole bool) bool {
+	for _, w := range words {
+		for i := 0; ; {
+			j := strings.Index(s[i:], w)
+			if j < 0 {
+				break
+			}
+
+			start, end := i+j, i+j+len(w)
+			if (start == 0 || !isAlnum(s[start-1])) && (!whole || end == len(s) || !isAlnum(s[end])) {
+				return true
+			}
+
+			i = start + 1
+		}
+	}
+
+	return false
+}
+
+func isAlnum(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func assetList(names ...string) []Asset {
+	assets := make([]Asset, 0, len(names))
+	for _, name := range names {
+		assets = append(assets, Asset{Name: name})
+	}
+
+	return assets
+}
+
+func TestRankAssets(t *testing.T) {
+	ripgrep := assetList(
+		"ripgrep-14.1.1-aarch64-apple-darwin.tar.gz",
+		"ripgrep-14.1.1-aarch64-apple-darwin.tar.gz.sha256",
+		"ripgrep-14.1.1-aarch64-unknown-linux-gnu.tar.gz",
+		"ripgrep-14.1.1-aarch64-unknown-linux-gnu.tar.gz.sha256",
+		"ripgrep-14.1.1-armv7-unknown-linux-gnueabihf.tar.gz",
+		"ripgrep-14.1.1-i686-pc-windows-msvc.zip",
+		"ripgrep-14.1.1-x86_64-apple-darwin.tar.gz",
+		"ripgrep-14.1.1-x86_64-pc-windows-msvc.zip",
+		"ripgrep-14.1.1-x86_64-unknown-linux-musl.tar.gz",
+		"ripgrep-14.1.1-x86_64-unknown-linux-musl.tar.gz.sha256",
+		"ripgrep_14.1.1-1_amd64.deb",
+		"ripgrep_14.1.1-1_amd64.deb.sha256",
+	)
+	bat := assetList(
+		"bat-v0.24.0-x86_64-unknown-linux-gnu.tar.gz",
+		"bat-v0.24.0-x86_64-unknown-linux-musl.tar.gz",
+		"bat-v0.24.0-x86_64-apple-darwin.tar.gz",
+		"bat-v0.24.0-x86_64-pc-windows-msvc.zip",
+		"bat_0.24.0_amd64.deb",
+		"bat-musl_0.24.0_amd64.deb",
+	)
+	goreleaser := assetList(
+		"checksums.txt",
+		"checksums.txt.sig",
+		"tool_1.2.3_linux_amd64.tar.gz",
+		"tool_1.2.3_linux_amd64.tar.gz.sbom.json",
+		"tool_1.2.3_linux_amd64_debug.tar.gz",
+		"tool_1.2.3_linux_amd64.rpm",
+		"tool_1.2.3_source.tar.gz",
+		"tool_1.2.3_darwin_all.tar.gz",
+		"tool_1.2.3_windows_amd64.zip",
+		"tool_1.2.3_windows_amd64.zip.pem",
+	)
+	raw := assetList(
+		"tool_linux_amd64",
+		"tool_linux_arm64",
+		"tool_darwin_arm64",
+		"tool_windows_amd64.exe",
+		"tool-x86_64.AppImage",
+		"checksums.txt",
+	)
+	mixed := assetList(
+		"tool_linux_amd64",
+		"tool_linux_amd64.tar.gz",
+	)
+	static := assetList(
+		"tool-linux-amd64-static.tar.gz",
+		"tool-linux-amd64-gnu.tar.gz",
+		"tool-linux-arm64.tar.gz",
+	)
+
+	tests := []struct {
+		name   string
+		assets []Asset
+		goos   string
+		goarch string
+		libc   string
+		want   string
+	}{
+		{"ripgrep linux musl host", ripgrep, "linux", "amd64", "musl", "ripgrep-14.1.1-x86_64-unknown-linux-musl.tar.gz"},
+		{"ripgrep linux arm64", ripgrep, "linux", "arm64", "gnu", "ripgrep-14.1.1-aarch64-unknown-linux-gnu.tar.gz"},
+		{"ripgrep darwin", ripgrep, "darwin", "arm64", "", "ripgrep-14.1.1-aarch64-apple-darwin.tar.gz"},
+		{"ripgrep windows", ripgrep, "windows", "amd64", "", "ripgrep-14.1.1-x86_64-pc-windows-msvc.zip"},
+		{"bat glibc host", bat, "linux", "amd64", "gnu", "bat-v0.24.0-x86_64-unknown-linux-gnu.tar.gz"},
+		{"bat musl host", bat, "linux", "amd64", "musl", "bat-v0.24.0-x86_64-unknown-linux-musl.tar.gz"},
+		{"goreleaser linux", goreleaser, "linux", "amd64", "gnu", "tool_1.2.3_linux_amd64.tar.gz"},
+		{"goreleaser universal darwin", goreleaser, "darwin", "arm64", "", "tool_1.2.3_darwin_all.tar.gz"},
+		{"raw linux", raw, "linux", "amd64", "gnu", "tool_linux_amd64"},
+		{"raw darwin", raw, "darwin", "arm64", "", "tool_darwin_arm64"},
+		{"raw windows exe", raw, "windows", "amd64", "", "tool_windows_amd64.exe"},
+		{"archive before raw", mixed, "linux", "amd64", "gnu", "tool_linux_amd64.tar.gz"},
+		{"static on musl host", static, "linux", "amd64", "musl", "tool-linux-amd64-static.tar.gz"},
+		{"gnu on glibc host", static, "linux", "amd64", "gnu", "tool-linux-amd64-gnu.tar.gz"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ranked := rankAssets(tc.assets, tc.goos, tc.goarch, tc.libc)
+			if len(ranked) == 0 || !ranked[0].Eligible {
+				t.Fatalf("rankAssets: no eligible asset")
+			}
+
+			if got := ranked[0].Asset.Name; got != tc.want {
+				t.Fatalf("rankAssets best = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRankAssetsArchitectures(t *testing.T) {
+	assets := assetList(
+		"tool-linux-armv6.tar.gz",
+		"tool-linux-armv7l.tar.gz",
+		"tool-linux-arm64.tar.gz",
+		"tool-linux-riscv64gc.tar.gz",
+		"tool-linux-powerpc64le.tar.gz",
+		"tool-linux-s390x.tar.gz",
+		"tool-linux-loongarch64.tar.gz",
+		"tool-linux-mipsel.tar.gz",
+		"tool-linux-mips64.tar.gz",
+	)
+
+	tests := []struct {
+		goarch string
+		goarm  int
+		want   string
+	}{
+		{"arm", 7, "tool-linux-armv7l.tar.gz"},
+		{"arm", 6, "tool-linux-armv6.tar.gz"},
+		{"arm64", 0, "tool-linux-arm64.tar.gz"},
+		{"riscv64", 0, "tool-linux-riscv64gc.tar.gz"},
+		{"ppc64le", 0, "tool-linux-powerpc64le.tar.gz"},
+		{"s390x", 0, "tool-linux-s390x.tar.gz"},
+		{"loong64", 0, "tool-linux-loongarch64.tar.gz"},
+		{"mipsle", 0, "tool-linux-mipsel.tar.gz"},
+		{"mips64", 0, "tool-linux-mips64.tar.gz"},
+	}
+
+	old := hostGOARM
+	t.Cleanup(func() { hostGOARM = old })
+
+	for _, tc := range tests {
+		hostGOARM = func() int { return tc.goarm }
+		ranked := rankAssets(assets, "linux", tc.goarch, "gnu")
+		if !ranked[0].Eligible || ranked[0].Asset.Name != tc.want {
+			t.Errorf("rankAssets(%s, GOARM=%d) best = %q, want %q", tc.goarch, tc.goarm, ranked[0].Asset.Name, tc.want)
+		}
+	}
+
+	hostGOARM = func() int { return 6 }
+	if s := scoreAsset(Asset{Name: "tool-linux-arm-v7.tar.gz"}, "linux", "arm", "gnu"); s.Eligible {
+		t.Errorf("armv7 asset eligible on an armv6 host: %v", s.Reasons)
+	}
+
+	if s := scoreAsset(Asset{Name: "tool-linux-mips64.tar.gz"}, "linux", "mips", "gnu"); s.Eligible {
+		t.Errorf("mips64 asset eligible for mips: %v", s.Reasons)
+	}
+}
+
+func TestRankAssetsRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		reason string
+	}{
+		{"tool_linux_amd64.tar.gz.sha256", "metadata"},
+		{"tool_linux_amd64.tar.gz.sig", "metadata"},
+		{"tool_linux_amd64.sbom", "metadata"},
+		{"tool_linux_amd64.deb", "OS package"},
+		{"tool_linux_amd64.exe", "not an archive or binary"},
+		{"tool_linux_amd64.pdf", "not an archive or binary"},
+		{"tool_darwin_amd64.tar.gz", "no linux in name"},
+		{"tool_linux_arm64.tar.gz", "no amd64 in name"},
+	}
+
+	for _, tc := range tests {
+		s := scoreAsset(Asset{Name: tc.name}, "linux", "amd64", "gnu")
+		if s.Eligible {
+			t.Errorf("scoreAsset(%q) eligible, want rejected", tc.name)
+			continue
+		}
+
+		if !strings.Contains(strings.Join(s.Reasons, ", "), tc.reason) {
+			t.Errorf("scoreAsset(%q) reasons = %v, want %q", tc.name, s.Reasons, tc.reason)
+		}
+	}
+}
+
+func TestHasWord(t *testing.T) {
+	tests := []struct {
+		s     string
+		words []string
+		want  bool
+	}{
+		{"tool_linux_arm64.tar.gz", []string{"arm"}, false},
+		{"tool_linux_arm.tar.gz", []string{"arm"}, true},
+		{"tool-x86_64-linux", []string{"x86_64"}, true},
+		{"tool_darwin_amd64", []string{"win"}, false},
+	}
+
+	for _, tc := range tests {
+		if got := hasWord(tc.s, tc.words); got != tc.want {
+			t.Errorf("hasWord(%q, %v) = %v, want %v", tc.s, tc.words, got, tc.want)
+		}
+	}
+}
+
+func TestPrintAssetRanking(t *testing.T) {
+	assets := assetList(
+		"tool_linux_amd64.tar.gz",
+		"tool_linux_amd64_debug.tar.gz",
+		"tool_linux_amd64.tar.gz.sha256",
+		"tool_darwin_amd64.tar.gz",
+	)
+
+	var buf bytes.Buffer
+	printAssetRanking(&buf, rankAssets(assets, "linux", "amd64", "gnu"), "linux", "amd64", "gnu", 3)
+	out := buf.String()
+
+	for _, want := range []string{
+		"asset candidates for linux/amd64 (gnu):",
+		"*   10  tool_linux_amd64.tar.gz  [+10 archive]",
+		"     5  tool_linux_amd64_debug.tar.gz  [+10 archive, -5 debug build]",
+		"... 1 more",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("printAssetRanking output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// maxSignatureFileSize bounds the size of signature and bundle assets.
+const maxSignatureFileSize int64 = 1 << 20
+
+// cosignSignatureSuffix and cosignCertificateSuffix name the detached
+// signature and signing certificate cosign sign-blob writes next to a file.
+// Certificates are only recognised so they are not mistaken for assets.
+const (
+	cosignSignatureSuffix   = ".sig"
+	cosignCertificateSuffix = ".pem"
+)
+
+// sigstoreBundleSuffixes name the Sigstore and legacy cosign bundles that
+// hold a signature and its verification material in one JSON file.
+var sigstoreBundleSuffixes = []string{".sigstore.json", ".sigstore", ".cosign.bundle", ".bundle"}
+
+// cosignTrust holds the keys release signatures are checked against, or nil
+// when signature verification is off.
+var cosignTrust *cosignVerifier
+
+// cosignVerifier checks cosign and Sigstore signatures offline against
+// trusted public keys. Certificates are not trusted: a CA vouches for a
+// signer's identity, which would need its own policy, and keyless
+// certificates expire minutes after signing.
+type cosignVerifier struct {
+	keys []crypto.PublicKey
+}
+
+// loadCosignTrust reads PEM public keys from path.
+func loadCosignTrust(path string) (*cosignVerifier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &cosignVerifier{}
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		switch block.Type {
+		case "PUBLIC KEY":
+			key, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+
+			v.keys = append(v.keys, key)
+		case "CERTIFICATE":
+			return nil, fmt.Errorf("%s: certificates are not supported, only public keys", path)
+		}
+	}
+
+	if len(v.keys) == 0 {
+		return nil, fmt.Errorf("%s: no PEM public key found", path)
+	}
+
+	return v, nil
+}
+
+// verify checks that sig signs data with a trusted key.
+func (v *cosignVerifier) verify(data, sig []byte) error {
+	for _, key := range v.keys {
+		if verifySignature(key, data, sig) == nil {
+			return nil
+		}
+	}
+
+	return errors.New("signature does not match the trusted key")
+}
+
+// verifySignature checks sig over data the way cosign signs blobs: ECDSA
+// and RSA over the SHA-256 digest (or the digest matching a larger ECDSA
+// curve), Ed25519 over the data itself.
+func verifySignature(key crypto.PublicKey, data, sig []byte) error {
+	hashes := []crypto.Hash{crypto.SHA256}
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P384():
+			hashes = append(hashes, crypto.SHA384)
+		case elliptic.P521():
+			hashes = append(hashes, crypto.SHA512)
+		}
+
+		for _, h := range hashes {
+			if ecdsa.VerifyASN1(k, digestOf(h, data), sig) {
+				return nil
+			}
+		}
+	case ed25519.PublicKey:
+		if ed25519.Verify(k, data, sig) {
+			return nil
+		}
+	case *rsa.PublicKey:
+		digest := digestOf(crypto.SHA256, data)
+		if rsa.VerifyPKCS1v15(k, crypto.SHA256, digest, sig) == nil ||
+			rsa.VerifyPSS(k, crypto.SHA256, digest, sig, nil) == nil {
+			return nil
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", key)
+	}
+
+	return errors.New("invalid signature")
+}
+
+func digestOf(h crypto.Hash, data []byte) []byte {
+	hh := h.New()
+	hh.Write(data)
+	return hh.Sum(nil)
+}
+
+// signatureAssets returns the cosign signatures and Sigstore bundles
+// published for the release file called name.
+func signatureAssets(assets []Asset, name string) []Asset {
+	var sigs []Asset
+	for _, a := range assets {
+		if signatureSuffix(a.Name, name) != "" {
+			sigs = append(sigs, a)
+		}
+	}
+
+	return sigs
+}
+
+// signatureSuffix returns the signature suffix sigName adds to name, such
+// as ".sig" or ".bundle", or "" when it is not a signature of name.
+func signatureSuffix(sigName, name string) string {
+	rest, ok := strings.CutPrefix(sigName, name)
+	if !ok {
+		return ""
+	}
+
+	for _, suffix := range append([]string{cosignSignatureSuffix}, sigstoreBundleSuffixes...) {
+		if strings.EqualFold(rest, suffix) {
+			return suffix
+		}
+	}
+
+	return ""
+}
+
+// isSignatureFile reports whether name looks like a signature, certificate
+// or bundle rather than the file it signs.
+func isSignatureFile(name string) bool {
+	lower := strings.ToLower(name)
+	for _, suffix := range slices.Concat([]string{cosignSignatureSuffix, cosignCertificateSuffix}, checksumSignatureSuffixes, sigstoreBundleSuffixes) {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withSignatures attaches the signatures of asset when signature
+// verification is on. An asset without signatures of its own is accepted
+// when a signed checksum file lists it: the signature is checked now and
+// the signed digest is what the download is verified against.
+func withSignatures(asset Asset, rc *releaseChecksums) (Asset, error) {
+	if cosignTrust == nil {
+		return asset, nil
+	}
+
+	if asset.signatures = signatureAssets(rc.assets, asset.Name); len(asset.signatures) > 0 {
+		return asset, nil
+	}
+
+	asset, ok, err := rc.withSignedDigest(asset, cosignTrust)
+	if err == nil && !ok {
+		err = fmt.Errorf("no signature found for %s or a checksum file listing it", asset.Name)
+	}
+
+	return asset, err
+}
+
+// verifyAssetSignatures checks the signatures attached to asset against the
+// downloaded bytes in f and rewinds f.
+func verifyAssetSignatures(asset Asset, f *os.File) error {
+	if len(asset.signatures) == 0 {
+		return nil
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+
+	if err := cosignTrust.verifyFile(asset.Name, data, asset.signatures); err != nil {
+		return err
+	}
+
+	_, err = f.Seek(0, io.SeekStart)
+	return err
+}
+
+// signatures returns the cosign signatures and bundles of the release file
+// called name.
+func (v *cosignVerifier) signatures(assets []Asset, name string) []Asset {
+	return signatureAssets(assets, name)
+}
+
+// verifyFile checks data, the content of the release file called name,
+// against every signature and bundle in sigs. At least one signature must
+// be present and all of them must verify.
+func (v *cosignVerifier) verifyFile(name string, data []byte, sigs []Asset) error {
+	files := make(map[string][]byte)
+	for _, s := range sigs {
+		b, err := fetchReleaseFile(s, maxSignatureFileSize)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", s.Name, err)
+		}
+
+		files[signatureSuffix(s.Name, name)] = b
+	}
+
+	verified := false
+	for _, suffix := range sigstoreBundleSuffixes {
+		b, ok := files[suffix]
+		if !ok {
+			continue
+		}
+
+		sig, err := parseSigstoreBundle(b)
+		if err == nil {
+			err = v.verify(data, sig)
+		}
+
+		if err != nil {
+			return fmt.Errorf("%s%s: %w", name, suffix, err)
+		}
+
+		verified = true
+	}
+
+	if b, ok := files[cosignSignatureSuffix]; ok {
+		sig, err := decodeMaybeBase64(b)
+		if err == nil {
+			err = v.verify(data, sig)
+		}
+
+		if err != nil {
+			return fmt.Errorf("%s%s: %w", name, cosignSignatureSuffix, err)
+		}
+
+		verified = true
+	}
+
+	if !verified {
+		return fmt.Errorf("no signature found for %s", name)
+	}
+
+	return nil
+}
+
+// sigstoreBundle covers both the Sigstore bundle format (messageSignature
+// or dsseEnvelope with verificationMaterial) and the legacy cosign --bundle
+// format (base64Signature).
+type sigstoreBundle struct {
+	MessageSignature *struct {
+		Signature []byte `json:"signature"`
+	} `json:"messageSignature"`
+	DSSEEnvelope *struct {
+		Payload     []byte `json:"payload"`
+		PayloadType string `json:"payloadType"`
+		Signatures  []struct {
+			Sig []byte `json:"sig"`
+		} `json:"signatures"`
+	} `json:"dsseEnvelope"`
+	VerificationMaterial struct {
+		Certificate *struct {
+			RawBytes []byte `json:"rawBytes"`
+		} `json:"certificate"`
+		X509CertificateChain *struct {
+			Certificates []struct {
+				RawBytes []byte `json:"rawBytes"`
+			} `json:"certificates"`
+		} `json:"x509CertificateChain"`
+		TlogEntries []tlogEntry `json:"tlogEntries"`
+	} `json:"verificationMaterial"`
+	Base64Signature string `json:"base64Signature"`
+}
+
+// parseSigstoreBundle returns the signature in a bundle. Any certificate
+// the bundle carries is ignored; the signature must verify with a trusted
+// key.
+func parseSigstoreBundle(data []byte) ([]byte, error) {
+	var b sigstoreBundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+
+	if b.Base64Signature != "" {
+		sig, err := base64.StdEncoding.DecodeString(b.Base64Signature)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bundle signature: %w", err)
+		}
+
+		return sig, nil
+	}
+
+	if b.MessageSignature == nil || len(b.MessageSignature.Signature) == 0 {
+		return nil, errors.New("bundle has no message signature")
+	}
+
+	return b.MessageSignature.Signature, nil
+}
+
+// certificateChain returns the signing certificate of a Sigstore bundle
+// followed by any intermediates it carries.
+func (b sigstoreBundle) certificateChain() ([]*x509.Certificate, error) {
+	var raw [][]byte
+	vm := b.VerificationMaterial
+	switch {
+	case vm.Certificate != nil:
+		raw = append(raw, vm.Certificate.RawBytes)
+	case vm.X509CertificateChain != nil:
+		for _, c := range vm.X509CertificateChain.Certificates {
+			raw = append(raw, c.RawBytes)
+		}
+	}
+
+	var chain []*x509.Certificate
+	for _, der := range raw {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bundle certificate: %w", err)
+		}
+
+		chain = append(chain, cert)
+	}
+
+	return chain, nil
+}
+
+// decodeMaybeBase64 returns data base64 decoded when it is base64 text, as
+// cosign writes signatures, and as is otherwise.
+func decodeMaybeBase64(data []byte) ([]byte, error) {
+	text := bytes.TrimSpace(data)
+	if len(text) == 0 {
+		return nil, errors.New("empty signature")
+	}
+
+	if decoded, err := base64.StdEncoding.DecodeString(string(text)); err == nil {
+		return decoded, nil
+	}
+
+	return data, nil
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveReleaseFiles serves files by name and returns them as release assets.
+func serveReleaseFiles(t *testing.T, files map[string][]byte) []Asset {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[strings.TrimPrefix(r.URL.Path, "/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+
+	var assets []Asset
+	for name, data := range files {
+		assets = append(assets, Asset{Name: name, BrowserDownloadURL: srv.URL + "/" + name, Size: int64(len(data))})
+	}
+
+	return assets
+}
+
+func setTestCosignTrust(t *testing.T, v *cosignVerifier) {
+	t.Helper()
+
+	old := cosignTrust
+	cosignTrust = v
+	t.Cleanup(func() { cosignTrust = old })
+}
+
+func newTestECDSAKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	return key
+}
+
+// cosignSign signs data the way cosign sign-blob does, returning the
+// base64 signature it writes to a .sig file.
+func cosignSign(t *testing.T, key *ecdsa.PrivateKey, data []byte) []byte {
+	t.Helper()
+
+	digest := sha256.Sum256(data)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("SignASN1: %v", err)
+	}
+
+	return []byte(base64.StdEncoding.EncodeToString(sig) + "\n")
+}
+
+func TestVerifyReleaseFileSignatures(t *testing.T) {
+	key := newTestECDSAKey(t)
+	otherKey := newTestECDSAKey(t)
+	setTestCosignTrust(t, &cosignVerifier{keys: []crypto.PublicKey{&key.PublicKey}})
+
+	data := []byte("release asset")
+	tests := []struct {
+		name    string
+		data    []byte
+		sig     []byte
+		wantErr string
+	}{
+		{"valid", data, cosignSign(t, key, data), ""},
+		{"tampered asset", []byte("tampered asset"), cosignSign(t, key, data), "signature does not match the trusted key"},
+		{"untrusted key", data, cosignSign(t, otherKey, data), "signature does not match the trusted key"},
+	}
+
+	for _, tc := range tests {
+		sigs := serveReleaseFiles(t, map[string][]byte{"tool.tar.gz.sig": tc.sig})
+		err := cosignTrust.verifyFile("tool.tar.gz", tc.data, sigs)
+		if tc.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: verifyFile: %v", tc.name, err)
+			}
+
+			continue
+		}
+
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("%s: verifyFile error = %v, want %q", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestVerifyReleaseFileSignaturesSigstoreBundle(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	setTestCosignTrust(t, &cosignVerifier{keys: []crypto.PublicKey{pub}})
+
+	data := []byte("release asset")
+	digest := sha256.Sum256(data)
+	bundle, err := json.Marshal(map[string]any{
+		"mediaType": "application/vnd.dev.sigstore.bundle.v0.3+json",
+		"verificationMaterial": map[string]any{
+			"publicKey": map[string]any{"hint": "test"},
+		},
+		"messageSignature": map[string]any{
+			"messageDigest": map[string]any{"algorithm": "SHA2_256", "digest": digest[:]},
+			"signature":     ed25519.Sign(priv, data),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	sigs := serveReleaseFiles(t, map[string][]byte{"tool.tar.gz.sigstore.json": bundle})
+	if err := cosignTrust.verifyFile("tool.tar.gz", data, sigs); err != nil {
+		t.Fatalf("verifyFile: %v", err)
+	}
+
+	if err := cosignTrust.verifyFile("tool.tar.gz", []byte("tampered"), sigs); err == nil {
+		t.Fatal("verifyFile accepted a tampered asset")
+	}
+}
+
+// newTestCertificatePEM returns a self-signed code signing certificate for
+// key, standing in for a keyless cosign certificate.
+func newTestCertificatePEM(t *testing.T, key *ecdsa.PrivateKey) []byte {
+	t.Helper()
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "signer"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestVerifyReleaseFileSignaturesIgnoresCertificate(t *testing.T) {
+	setTestCosignTrust(t, &cosignVerifier{keys: []crypto.PublicKey{&newTestECDSAKey(t).PublicKey}})
+
+	// The signing certificate next to the signature does not make its key
+	// trusted.
+	signer := newTestECDSAKey(t)
+	data := []byte("release asset")
+	sigs := serveReleaseFiles(t, map[string][]byte{
+		"tool.tar.gz.sig": cosignSign(t, signer, data),
+		"tool.tar.gz.pem": []byte(base64.StdEncoding.EncodeToString(newTestCertificatePEM(t, signer))),
+	})
+
+	err := cosignTrust.verifyFile("tool.tar.gz", data, sigs)
+	if err == nil || !strings.Contains(err.Error(), "does not match the trusted key") {
+		t.Fatalf("verifyFile error = %v, want untrusted key", err)
+	}
+}
+
+func TestWithSignaturesUsesSignedChecksumFile(t *testing.T) {
+	key := newTestECDSAKey(t)
+	setTestCosignTrust(t, &cosignVerifier{keys: []crypto.PublicKey{&key.PublicKey}})
+
+	sum := sha256.Sum256([]byte("release asset"))
+	checksums := []byte(hex.EncodeToString(sum[:]) + "  tool.tar.gz\n")
+	assets := serveReleaseFiles(t, map[string][]byte{
+		"tool.tar.gz":       []byte("release asset"),
+		"checksums.txt":     checksums,
+		"checksums.txt.sig": cosignSign(t, key, checksums),
+	})
+
+	got, err := withSignatures(Asset{Name: "tool.tar.gz"}, newReleaseChecksums(assets))
+	if err != nil {
+		t.Fatalf("withSignatures: %v", err)
+	}
+
+	if want := "sha256:" + hex.EncodeToString(sum[:]); got.Digest != want {
+		t.Fatalf("withSignatures digest = %q, want %q", got.Digest, want)
+	}
+
+	if _, err := withSignatures(Asset{Name: "tool.tar.gz", Digest: "sha256:" + strings.Repeat("0", 64)}, newReleaseChecksums(assets)); err == nil {
+		t.Fatal("withSignatures accepted a digest that contradicts the signed checksum")
+	}
+}
+
+func TestWithSignaturesRequiresSignature(t *testing.T) {
+	setTestCosignTrust(t, &cosignVerifier{keys: []crypto.PublicKey{&newTestECDSAKey(t).PublicKey}})
+
+	assets := serveReleaseFiles(t, map[string][]byte{
+		"tool.tar.gz":   []byte("release asset"),
+		"checksums.txt": []byte(strings.Repeat("0", 64) + "  tool.tar.gz\n"),
+	})
+
+	_, err := withSignatures(Asset{Name: "tool.tar.gz"}, newReleaseChecksums(assets))
+	if err == nil || !strings.Contains(err.Error(), "no signature found") {
+		t.Fatalf("withSignatures error = %v, want no signature found", err)
+	}
+}
+
+func TestReleaseChecksumsFetchesEachFileOnce(t *testing.T) {
+	key := newTestECDSAKey(t)
+	setTestCosignTrust(t, &cosignVerifier{keys: []crypto.PublicKey{&key.PublicKey}})
+
+	sum := sha256.Sum256([]byte("release asset"))
+	checksums := []byte(hex.EncodeToString(sum[:]) + "  tool.tar.gz\n")
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/checksums.txt":
+			requests++
+			w.Write(checksums)
+		case "/checksums.txt.sig":
+			w.Write(cosignSign(t, key, checksums))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	sums := newReleaseChecksums([]Asset{
+		{Name: "tool.tar.gz", BrowserDownloadURL: srv.URL + "/tool.tar.gz"},
+		{Name: "checksums.txt", BrowserDownloadURL: srv.URL + "/checksums.txt"},
+		{Name: "checksums.txt.sig", BrowserDownloadURL: srv.URL + "/checksums.txt.sig"},
+	})
+
+	asset, err := withSignatures(withPublishedDigest(Asset{Name: "tool.tar.gz"}, sums), sums)
+	if err != nil {
+		t.Fatalf("withSignatures: %v", err)
+	}
+
+	if want := "sha256:" + hex.EncodeToString(sum[:]); asset.Digest != want {
+		t.Fatalf("digest = %q, want %q", asset.Digest, want)
+	}
+
+	if requests != 1 {
+		t.Fatalf("checksums.txt fetched %d times, want 1", requests)
+	}
+}
+
+func TestDownloadAndVerifyRejectsBadSignature(t *testing.T) {
+	key := newTestECDSAKey(t)
+	setTestCosignTrust(t, &cosignVerifier{keys: []crypto.PublicKey{&key.PublicKey}})
+
+	old := options
+	t.Cleanup(func() { options = old })
+	options.cacheDir = ""
+
+	assets := serveReleaseFiles(t, map[string][]byte{
+		"tool.tar.gz":     []byte("tampered asset"),
+		"tool.tar.gz.sig": cosignSign(t, key, []byte("release asset")),
+	})
+
+	var asset Asset
+	for _, a := range assets {
+		if a.Name == "tool.tar.gz" {
+			asset = a
+		}
+	}
+
+	asset, err := withSignatures(asset, newReleaseChecksums(assets))
+	if err != nil {
+		t.Fatalf("withSignatures: %v", err)
+	}
+
+	captureStderr(t, func() {
+		_, err = downloadAndVerify(asset, 1<<20)
+	})
+	if err == nil || !strings.Contains(err.Error(), "verifying signature") {
+		t.Fatalf("downloadAndVerify error = %v, want signature failure", err)
+	}
+}
+
+func TestLoadCosignTrust(t *testing.T) {
+	key := newTestECDSAKey(t)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey: %v", err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cosign.pub")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	v, err := loadCosignTrust(path)
+	if err != nil {
+		t.Fatalf("loadCosignTrust: %v", err)
+	}
+
+	if len(v.keys) != 1 {
+		t.Fatalf("loadCosignTrust loaded %d keys, want 1", len(v.keys))
+	}
+
+	empty := filepath.Join(dir, "empty.pub")
+	if err := os.WriteFile(empty, []byte("not a key\n"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if _, err := loadCosignTrust(empty); err == nil {
+		t.Fatal("loadCosignTrust accepted a file without keys")
+	}
+
+	cert := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(cert, newTestCertificatePEM(t, key), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if _, err := loadCosignTrust(cert); err == nil || !strings.Contains(err.Error(), "certificates are not supported") {
+		t.Fatalf("loadCosignTrust error = %v, want certificates rejected", err)
+	}
+}